@@ -0,0 +1,28 @@
+// Command squiggle-vet combines debugcheck with previews of the
+// refactoring_tool rewrites (rename-fn, rename-type, add-param) behind a
+// single vettool entry point, so the whole set can be invoked as
+//
+//	go vet -vettool=$(which squiggle-vet) ./...
+//
+// The rename-fn and rename-type analyzers attach a SuggestedFix to each
+// diagnostic, so running squiggle-vet -fix applies those renames (matched
+// by name and object kind). add-param only reports; running
+// refactoring_tool itself with -write is still required to rewrite files
+// for it.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/multichecker"
+
+	"github.com/KindMan0011/squiggleconf-2025/tutorials/go/debugging/ast/debugcheck"
+	"github.com/KindMan0011/squiggleconf-2025/tutorials/go/debugging/ast/refactorcheck"
+)
+
+func main() {
+	multichecker.Main(
+		debugcheck.Analyzer,
+		refactorcheck.RenameFuncAnalyzer,
+		refactorcheck.RenameTypeAnalyzer,
+		refactorcheck.AddParamAnalyzer,
+	)
+}
@@ -0,0 +1,229 @@
+// Package cache provides a size-bounded, parallel-safe LRU for parsed
+// Go source, so tools that repeatedly look at the same files (the
+// lint/codegen tools under this directory) don't pay for a
+// fresh parser.ParseFile on every pass over a large tree. It's the same
+// shape as the filecache/LRU gopls keeps in front of its parser.
+package cache
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"sync"
+)
+
+// DefaultMaxBytes is the default cache budget: ~100 MiB of estimated
+// source size, matching the request this package was built against.
+const DefaultMaxBytes = 100 * 1024 * 1024
+
+// Entry is what a cache hit returns: the file set a parsed *ast.File was
+// parsed against (needed to turn token.Pos back into line/column), the file
+// itself, and - when the caller already paid for a typecheck - the
+// resulting *types.Info.
+//
+// Parse only ever populates Fset/File: it exists to front a plain
+// parser.ParseFile, and type-checking a single file in isolation isn't
+// meaningful (it needs the whole package). Info is here so callers that do
+// their own typechecking of the same file, such as custom_linter.go's
+// loadTyped (which type-checks via golang.org/x/tools/go/packages), can
+// stash the *types.Info they already computed alongside the cached syntax
+// tree instead of every TypedRule re-deriving it. refactoring_tool.go no
+// longer has a use for this: it moved its own parsing to packages.Load
+// directly rather than a bare parser.ParseFile loop, so it never goes
+// through this cache at all.
+type Entry struct {
+	Fset *token.FileSet
+	File *ast.File
+	Info *types.Info
+}
+
+// key identifies a cached parse by the on-disk identity it was produced
+// from, so a write to the file (which changes mtime and usually size)
+// invalidates it automatically without an explicit signal.
+type key struct {
+	path  string
+	mtime int64
+	size  int64
+}
+
+type node struct {
+	key   key
+	entry Entry
+	size  int64
+	prev  *node
+	next  *node
+}
+
+// Cache is a size-bounded LRU of Entry, safe for concurrent use. The zero
+// value is not usable; construct with New.
+type Cache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	curBytes int64
+	items    map[key]*node
+	head     *node // most recently used
+	tail     *node // least recently used
+
+	inflight map[key]*call
+}
+
+type call struct {
+	done  chan struct{}
+	entry Entry
+	err   error
+}
+
+// New creates a Cache with the given byte budget. A maxBytes of 0 selects
+// DefaultMaxBytes.
+func New(maxBytes int64) *Cache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &Cache{
+		maxBytes: maxBytes,
+		items:    make(map[key]*node),
+		inflight: make(map[key]*call),
+	}
+}
+
+// Parse returns the parsed AST for path, consulting the cache first. Each
+// entry is keyed on (path, mtime, size), so a file edited between calls is
+// reparsed; its stale entry is no longer hit and stays in the cache until
+// LRU pressure evicts it (or Invalidate drops it). Concurrent callers asking for the
+// same path at the same time share a single parse via single-flight.
+func (c *Cache) Parse(path string) (Entry, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+	k := key{path: path, mtime: fi.ModTime().UnixNano(), size: fi.Size()}
+
+	if entry, ok := c.get(k); ok {
+		return entry, nil
+	}
+
+	entry, err := c.singleflight(k, func() (Entry, error) {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return Entry{}, fmt.Errorf("failed to parse file: %v", err)
+		}
+		return Entry{Fset: fset, File: file}, nil
+	})
+	if err != nil {
+		return Entry{}, err
+	}
+
+	c.set(k, entry, fi.Size())
+	return entry, nil
+}
+
+// Invalidate drops any cached entry for path, regardless of key, so a
+// caller that just wrote new content to it (via os.WriteFile) doesn't see
+// a stale parse on the next Parse call.
+func (c *Cache) Invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, n := range c.items {
+		if k.path == path {
+			c.unlinkLocked(n)
+			delete(c.items, k)
+			c.curBytes -= n.size
+		}
+	}
+}
+
+func (c *Cache) get(k key) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, ok := c.items[k]
+	if !ok {
+		return Entry{}, false
+	}
+	c.moveToFrontLocked(n)
+	return n.entry, true
+}
+
+func (c *Cache) set(k key, entry Entry, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, ok := c.items[k]; ok {
+		c.unlinkLocked(existing)
+		c.curBytes -= existing.size
+		delete(c.items, k)
+	}
+
+	n := &node{key: k, entry: entry, size: size}
+	c.items[k] = n
+	c.pushFrontLocked(n)
+	c.curBytes += size
+
+	for c.curBytes > c.maxBytes && c.tail != nil {
+		evict := c.tail
+		c.unlinkLocked(evict)
+		delete(c.items, evict.key)
+		c.curBytes -= evict.size
+	}
+}
+
+// singleflight ensures only one goroutine parses a given key at a time;
+// callers that arrive while a parse is in flight wait for it and reuse its
+// result instead of reparsing.
+func (c *Cache) singleflight(k key, fn func() (Entry, error)) (Entry, error) {
+	c.mu.Lock()
+	if inflight, ok := c.inflight[k]; ok {
+		c.mu.Unlock()
+		<-inflight.done
+		return inflight.entry, inflight.err
+	}
+	call := &call{done: make(chan struct{})}
+	c.inflight[k] = call
+	c.mu.Unlock()
+
+	call.entry, call.err = fn()
+	close(call.done)
+
+	c.mu.Lock()
+	delete(c.inflight, k)
+	c.mu.Unlock()
+
+	return call.entry, call.err
+}
+
+func (c *Cache) moveToFrontLocked(n *node) {
+	if c.head == n {
+		return
+	}
+	c.unlinkLocked(n)
+	c.pushFrontLocked(n)
+}
+
+func (c *Cache) pushFrontLocked(n *node) {
+	n.prev = nil
+	n.next = c.head
+	if c.head != nil {
+		c.head.prev = n
+	}
+	c.head = n
+	if c.tail == nil {
+		c.tail = n
+	}
+}
+
+func (c *Cache) unlinkLocked(n *node) {
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else if c.head == n {
+		c.head = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else if c.tail == n {
+		c.tail = n.prev
+	}
+	n.prev, n.next = nil, nil
+}
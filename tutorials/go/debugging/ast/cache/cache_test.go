@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFixtures writes n trivial, distinct Go source files under dir and
+// returns their paths, for benchmarking Parse against a corpus roughly the
+// size of a real tree.
+func writeFixtures(b *testing.B, dir string, n int) []string {
+	b.Helper()
+	paths := make([]string, n)
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("f%d.go", i))
+		src := fmt.Sprintf("package fixtures\n\nfunc F%d() int { return %d }\n", i, i)
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			b.Fatal(err)
+		}
+		paths[i] = path
+	}
+	return paths
+}
+
+// BenchmarkParse_Cold parses a 1k-file corpus with a fresh Cache every
+// iteration, so every Parse call misses and pays for a full
+// parser.ParseFile - this is the cost the cache is meant to avoid on
+// repeated passes.
+func BenchmarkParse_Cold(b *testing.B) {
+	dir := b.TempDir()
+	paths := writeFixtures(b, dir, 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := New(0)
+		for _, p := range paths {
+			if _, err := c.Parse(p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkParse_Warm reuses one Cache across the whole 1k-file corpus, so
+// after the first pass every Parse of an unchanged file is a cache hit -
+// this is the steady-state cost for tools (lint -fix, the code generator,
+// ...) that revisit the same tree repeatedly.
+func BenchmarkParse_Warm(b *testing.B) {
+	dir := b.TempDir()
+	paths := writeFixtures(b, dir, 1000)
+	c := New(0)
+	for _, p := range paths {
+		if _, err := c.Parse(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			if _, err := c.Parse(p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -1,32 +1,366 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"go/ast"
-	"go/parser"
+	"go/format"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/KindMan0011/squiggleconf-2025/tutorials/go/debugging/ast/cache"
 )
 
+// parseCache is shared by every lintFile call in this process, so a file
+// linted twice within one process isn't reparsed from disk. It lives only
+// as long as the process; separate runs each parse their files afresh.
+var parseCache = cache.New(0)
+
 // Issue represents a linting issue
 type Issue struct {
 	Pos      token.Position
 	Message  string
 	Severity string
+	// Check is the reporting rule's Name(), the check ID that //lint:ignore
+	// comments and .linterignore entries refer to. lintFile fills it in
+	// after calling Check, so individual rules don't need to know their
+	// own Name() when building an Issue.
+	Check string
 }
 
-// LintRule defines a rule for linting
+// LintRule defines a rule for linting. ctx carries the deadline set by a
+// loaded LinterConfig's Deadline field, if any - most rules can ignore it,
+// but a rule doing something slow enough to matter should check ctx.Err()
+// periodically.
 type LintRule interface {
-	Check(fset *token.FileSet, file *ast.File) []Issue
+	Check(ctx context.Context, fset *token.FileSet, file *ast.File) []Issue
 	Name() string
 	Description() string
+	// Configure applies this rule's LinterOptions entry from the loaded
+	// config (raw is nil if the config has none for this rule's Name()).
+	// Rules with nothing to configure can embed NoConfig to satisfy this.
+	Configure(raw json.RawMessage) error
+}
+
+// NoConfig is embedded by rules with no LinterOptions of their own, so
+// they satisfy LintRule.Configure without writing a no-op method.
+type NoConfig struct{}
+
+// Configure is a no-op: the embedding rule has nothing to configure.
+func (NoConfig) Configure(raw json.RawMessage) error { return nil }
+
+// TypedRule is implemented by rules that need resolved type information
+// (go/types), not just the parsed AST - e.g. to tell a call that actually
+// returns an error from one that merely has a suggestive name. lintFile
+// only pays for packages.Load (a full package typecheck, far more
+// expensive than parseCache's bare parse) when at least one active rule
+// implements this; plain LintRules keep running off the cached AST alone.
+type TypedRule interface {
+	LintRule
+	CheckTyped(ctx context.Context, fset *token.FileSet, file *ast.File, info *types.Info) []Issue
+}
+
+// ruleRegistry is the set of rules main can run, keyed by Name(), so
+// adding a rule means registering it (see registerRule) instead of
+// editing main's rule list by hand.
+var ruleRegistry = map[string]LintRule{}
+
+// registerRule adds rule to ruleRegistry under its Name(). It panics on a
+// duplicate name, which can only be a programming error: two rules
+// claiming the same check ID.
+func registerRule(rule LintRule) {
+	name := rule.Name()
+	if _, exists := ruleRegistry[name]; exists {
+		panic(fmt.Sprintf("lint rule %q registered twice", name))
+	}
+	ruleRegistry[name] = rule
+}
+
+// Fixer is implemented by rules that can rewrite the file to resolve the
+// issues they report, in addition to just reporting them. lintFile calls
+// Fix instead of printing a rule's issues when -fix is set.
+type Fixer interface {
+	// Fix rewrites file (parsed from path, against fset) to resolve this
+	// rule's issues and writes the result back to path, preserving its
+	// original file mode. It reports whether anything was written.
+	Fix(fset *token.FileSet, file *ast.File, path string) (changed bool, err error)
+}
+
+// Ignore is a suppression source consulted after a rule reports its
+// issues: Matches reports whether the issue found at pos, for check,
+// should be dropped instead of reported.
+type Ignore interface {
+	Matches(pos token.Position, check string) bool
+}
+
+// LineIgnore suppresses issues for specific checks at one source line,
+// populated from a `//lint:ignore <checks> <reason>` comment attached to
+// the statement or declaration it documents.
+type LineIgnore struct {
+	File   string
+	Line   int
+	Checks []string
+
+	// matched records whether this LineIgnore has ever actually suppressed
+	// an issue, so lintFile can warn about ones that never fired (a stale
+	// suppression left behind after the code it covered changed).
+	matched bool
+}
+
+func (ig *LineIgnore) Matches(pos token.Position, check string) bool {
+	if !samePath(pos.Filename, ig.File) || pos.Line != ig.Line || !checksMatch(ig.Checks, check) {
+		return false
+	}
+	ig.matched = true
+	return true
+}
+
+// FileIgnore suppresses issues for specific checks anywhere in one file,
+// populated from a `//lint:file-ignore <checks> <reason>` comment.
+type FileIgnore struct {
+	File   string
+	Checks []string
+}
+
+func (ig FileIgnore) Matches(pos token.Position, check string) bool {
+	return samePath(pos.Filename, ig.File) && checksMatch(ig.Checks, check)
+}
+
+// samePath reports whether a and b refer to the same file, tolerating one
+// being relative and the other absolute - as happens once a TypedRule's
+// Issue.Pos comes from packages.Load (which reports absolute paths) while
+// //lint:ignore directives were keyed off parseCache's bare parse (whatever
+// form -dir was given in).
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// checksMatch reports whether check is named in checks, or checks allows
+// everything via "*" or "ALL" (the staticcheck convention for a
+// //lint:ignore directive with no specific check list).
+func checksMatch(checks []string, check string) bool {
+	for _, c := range checks {
+		if c == "*" || c == "ALL" || c == check {
+			return true
+		}
+	}
+	return false
+}
+
+// RepoIgnoreRule is one entry of a repository-level .linterignore file:
+// Files and Checks are each glob patterns (matched via filepath.Match,
+// against the full linted path and its base name). An issue is suppressed
+// if any Files pattern matches the file and any Checks pattern matches the
+// check; an empty list matches everything.
+type RepoIgnoreRule struct {
+	Files  []string `json:"files"`
+	Checks []string `json:"checks"`
+}
+
+func (r RepoIgnoreRule) matches(file, check string) bool {
+	if len(r.Files) > 0 && !matchesAnyGlob(r.Files, file) {
+		return false
+	}
+	if len(r.Checks) > 0 && !matchesAnyGlob(r.Checks, check) {
+		return false
+	}
+	return true
+}
+
+func matchesAnyGlob(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, err := filepath.Match(p, s); err == nil && ok {
+			return true
+		}
+		if ok, err := filepath.Match(p, filepath.Base(s)); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// repoIgnoreSet adapts a .linterignore file's rules to the Ignore
+// interface.
+type repoIgnoreSet []RepoIgnoreRule
+
+func (rules repoIgnoreSet) Matches(pos token.Position, check string) bool {
+	for _, r := range rules {
+		if r.matches(pos.Filename, check) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadLinterIgnore reads a repository-level ignore file: JSON by default,
+// or the small TOML subset parseLinterIgnoreTOML understands when path
+// ends in ".toml". A missing file is not an error - it just means no
+// repo-level suppressions are configured.
+func loadLinterIgnore(path string) ([]RepoIgnoreRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if strings.HasSuffix(path, ".toml") {
+		return parseLinterIgnoreTOML(data), nil
+	}
+
+	var rules []RepoIgnoreRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return rules, nil
+}
+
+// parseLinterIgnoreTOML parses the one shape a .linterignore file needs:
+// zero or more [[rule]] tables, each with a files and/or checks array of
+// quoted strings. It's hand-rolled rather than pulling in a TOML library,
+// since this is the only shape .linterignore ever takes.
+func parseLinterIgnoreTOML(data []byte) []RepoIgnoreRule {
+	var rules []RepoIgnoreRule
+	var cur *RepoIgnoreRule
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "[[rule]]" {
+			rules = append(rules, RepoIgnoreRule{})
+			cur = &rules[len(rules)-1]
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "files":
+			cur.Files = parseTOMLStringArray(value)
+		case "checks":
+			cur.Checks = parseTOMLStringArray(value)
+		}
+	}
+
+	return rules
+}
+
+// parseTOMLStringArray parses a TOML-style `["a", "b"]` literal into its
+// quoted elements.
+func parseTOMLStringArray(s string) []string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "[")
+	s = strings.TrimSuffix(s, "]")
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.Trim(strings.TrimSpace(part), `"`)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// cutPrefix is like strings.CutPrefix, spelled out for portability: it
+// reports whether s starts with prefix, and if so returns the remainder
+// trimmed of leading and trailing whitespace.
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return strings.TrimSpace(s[len(prefix):]), true
+}
+
+// splitDirectiveArgs splits a //lint:ignore/file-ignore directive's
+// argument text ("<checks> <reason>") into its comma-separated check list
+// and the free-form reason, e.g. "SA1000,SA1001 still used by callers" ->
+// (["SA1000", "SA1001"], "still used by callers").
+func splitDirectiveArgs(s string) (checks []string, reason string) {
+	fields := strings.SplitN(s, " ", 2)
+	if len(fields) > 1 {
+		reason = strings.TrimSpace(fields[1])
+	}
+	if fields[0] == "" {
+		return nil, reason
+	}
+	return strings.Split(fields[0], ","), reason
+}
+
+// parseLintDirectives scans file's comments for //lint:ignore and
+// //lint:file-ignore directives. A //lint:ignore is keyed by the position
+// of the statement or declaration it documents (via ast.NewCommentMap),
+// not the comment's own line, so it still applies correctly if reformatted
+// code shifts line numbers. //lint:file-ignore isn't tied to any node, so
+// it's read directly off file.Comments instead.
+func parseLintDirectives(fset *token.FileSet, file *ast.File) ([]*LineIgnore, []FileIgnore) {
+	var lineIgnores []*LineIgnore
+	var fileIgnores []FileIgnore
+
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if rest, ok := cutPrefix(text, "lint:file-ignore"); ok {
+				checks, _ := splitDirectiveArgs(rest)
+				fileIgnores = append(fileIgnores, FileIgnore{
+					File:   fset.Position(c.Pos()).Filename,
+					Checks: checks,
+				})
+			}
+		}
+	}
+
+	cmap := ast.NewCommentMap(fset, file, file.Comments)
+	for node, groups := range cmap {
+		for _, cg := range groups {
+			for _, c := range cg.List {
+				text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+				if rest, ok := cutPrefix(text, "lint:ignore"); ok {
+					checks, _ := splitDirectiveArgs(rest)
+					pos := fset.Position(node.Pos())
+					lineIgnores = append(lineIgnores, &LineIgnore{
+						File:   pos.Filename,
+						Line:   pos.Line,
+						Checks: checks,
+					})
+				}
+			}
+		}
+	}
+
+	return lineIgnores, fileIgnores
 }
 
 // UnusedImportRule checks for unused imports
-type UnusedImportRule struct{}
+type UnusedImportRule struct {
+	NoConfig
+}
 
 func (r UnusedImportRule) Name() string {
 	return "unused-import"
@@ -36,70 +370,101 @@ func (r UnusedImportRule) Description() string {
 	return "Detects unused imports in the code"
 }
 
-func (r UnusedImportRule) Check(fset *token.FileSet, file *ast.File) []Issue {
-	var issues []Issue
-	
-	// Get all imports
-	imports := make(map[string]token.Position)
-	for _, imp := range file.Imports {
-		name := ""
-		if imp.Name != nil {
-			// Named import
-			name = imp.Name.Name
-			if name == "_" {
-				// Blank import is used for side effects
+// unusedImportSpecs returns the import specs in file that astutil reports
+// nothing in file actually references. astutil.UsesImport looks for
+// selectors on the spec's local name: a renamed import is matched by its
+// alias, but for an unnamed import the name is taken from the last element
+// of the import path, so a package whose name differs from that element
+// (e.g. gopkg.in/yaml.v3, package yaml) is reported unused. Blank and dot
+// imports are skipped: a blank import is kept for its side effects, and a
+// dot import's uses can't be traced back to it by name.
+func unusedImportSpecs(fset *token.FileSet, file *ast.File) []*ast.ImportSpec {
+	var unused []*ast.ImportSpec
+	for _, group := range astutil.Imports(fset, file) {
+		for _, spec := range group {
+			if spec.Name != nil && (spec.Name.Name == "_" || spec.Name.Name == ".") {
 				continue
 			}
-			if name == "." {
-				// Dot import is hard to track, skip for now
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
 				continue
 			}
-		} else {
-			// Regular import, extract the package name
-			path := strings.Trim(imp.Path.Value, "\"")
-			parts := strings.Split(path, "/")
-			name = parts[len(parts)-1]
-		}
-		imports[name] = fset.Position(imp.Pos())
-	}
-	
-	// Find all identifiers in the file
-	ast.Inspect(file, func(n ast.Node) bool {
-		if ident, ok := n.(*ast.Ident); ok {
-			// Skip checking package selectors (e.g., fmt.Printf)
-			if _, ok := imports[ident.Name]; ok {
-				// This identifier matches an import name, remove it from the map
-				delete(imports, ident.Name)
-			}
-		}
-		
-		// For SelectorExpr (e.g., fmt.Printf), check the package part
-		if sel, ok := n.(*ast.SelectorExpr); ok {
-			if x, ok := sel.X.(*ast.Ident); ok {
-				if _, ok := imports[x.Name]; ok {
-					// Used import, remove it from the map
-					delete(imports, x.Name)
-				}
+			if !astutil.UsesImport(file, path) {
+				unused = append(unused, spec)
 			}
 		}
-		
-		return true
-	})
-	
-	// Remaining imports in the map are unused
-	for name, pos := range imports {
+	}
+	return unused
+}
+
+// importDisplayName returns the name an unused-import issue should show
+// for spec: its local alias if it has one, otherwise its import path.
+func importDisplayName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	return strings.Trim(spec.Path.Value, "\"")
+}
+
+func (r UnusedImportRule) Check(ctx context.Context, fset *token.FileSet, file *ast.File) []Issue {
+	if ctx.Err() != nil {
+		return nil
+	}
+	var issues []Issue
+	for _, spec := range unusedImportSpecs(fset, file) {
 		issues = append(issues, Issue{
-			Pos:      pos,
-			Message:  fmt.Sprintf("Unused import: %s", name),
-			Severity: "warning",
+			Pos:      fset.Position(spec.Pos()),
+			Message:  fmt.Sprintf("Unused import: %s", importDisplayName(spec)),
+			Severity: "error",
 		})
 	}
-	
 	return issues
 }
 
+// Fix implements Fixer: it deletes every unused import, re-sorts the
+// remaining import block, and rewrites path in place.
+func (r UnusedImportRule) Fix(fset *token.FileSet, file *ast.File, path string) (bool, error) {
+	unused := unusedImportSpecs(fset, file)
+	if len(unused) == 0 {
+		return false, nil
+	}
+
+	for _, spec := range unused {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil {
+			astutil.DeleteNamedImport(fset, file, spec.Name.Name, importPath)
+		} else {
+			astutil.DeleteImport(fset, file, importPath)
+		}
+	}
+	ast.SortImports(fset, file)
+
+	// Preserve the file's original permissions rather than defaulting to
+	// whatever os.WriteFile would pick, so this doesn't clobber a mode set
+	// deliberately (and so Windows permissions survive the round trip).
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return false, fmt.Errorf("failed to format %s: %v", path, err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), fi.Mode()&os.ModePerm); err != nil {
+		return false, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return true, nil
+}
+
 // ErrorReturnRule checks if errors are being checked
-type ErrorReturnRule struct{}
+type ErrorReturnRule struct {
+	NoConfig
+}
 
 func (r ErrorReturnRule) Name() string {
 	return "error-check"
@@ -109,57 +474,145 @@ func (r ErrorReturnRule) Description() string {
 	return "Ensures that errors returned from function calls are checked"
 }
 
-func (r ErrorReturnRule) Check(fset *token.FileSet, file *ast.File) []Issue {
+// Check satisfies LintRule, but ErrorReturnRule needs resolved types to
+// tell a genuine error return from a same-shaped call that isn't one (see
+// CheckTyped) - without them there's nothing safe to report, so lintFile
+// never calls this; it's here only so ErrorReturnRule also type-checks as
+// a plain LintRule.
+func (r ErrorReturnRule) Check(ctx context.Context, fset *token.FileSet, file *ast.File) []Issue {
+	return nil
+}
+
+// errorIface is the built-in error interface, used to test whether a
+// call's result type satisfies it.
+var errorIface = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+// isErrorType reports whether t implements the error interface.
+func isErrorType(t types.Type) bool {
+	return t != nil && types.Implements(t, errorIface)
+}
+
+// callReturnsError reports whether any of call's results implements error.
+// go/types records a multi-result call's type as a *types.Tuple in
+// info.TypeOf regardless of how the call is used, so this covers both an
+// ignored multi-value call (*ast.ExprStmt) and one assigned to _.
+func callReturnsError(info *types.Info, call *ast.CallExpr) bool {
+	t := info.TypeOf(call)
+	if tuple, ok := t.(*types.Tuple); ok {
+		for i := 0; i < tuple.Len(); i++ {
+			if isErrorType(tuple.At(i).Type()) {
+				return true
+			}
+		}
+		return false
+	}
+	return isErrorType(t)
+}
+
+// infallibleWriterTypes are receiver/writer types whose Write* methods are
+// documented to always return a nil error - the same default exclude set
+// errcheck ships for bytes.Buffer and strings.Builder.
+var infallibleWriterTypes = map[string]bool{
+	"bytes.Buffer":     true,
+	"*bytes.Buffer":    true,
+	"strings.Builder":  true,
+	"*strings.Builder": true,
+}
+
+// isInfallibleWrite reports whether call is a method on a known
+// always-succeeds writer (bytes.Buffer/strings.Builder's Write* methods),
+// or a free function - fmt.Fprintf/Fprintln/Fprint, go/format.Node - whose
+// first argument is one. These never return a non-nil error in practice,
+// so flagging their ignored result is just noise.
+func isInfallibleWrite(info *types.Info, call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	if recvType := info.TypeOf(sel.X); recvType != nil && infallibleWriterTypes[recvType.String()] {
+		return true
+	}
+
+	name, _ := getFunctionName(call)
+	switch name {
+	case "fmt.Fprintf", "fmt.Fprintln", "fmt.Fprint", "format.Node", "printer.Fprint":
+		if len(call.Args) > 0 {
+			if t := info.TypeOf(call.Args[0]); t != nil && infallibleWriterTypes[t.String()] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckTyped implements TypedRule. It flags a call's error result as
+// unchecked when it's either dropped entirely (*ast.ExprStmt) or assigned
+// to the blank identifier, using info to identify an error-typed result
+// instead of guessing from the called function's name.
+func (r ErrorReturnRule) CheckTyped(ctx context.Context, fset *token.FileSet, file *ast.File, info *types.Info) []Issue {
+	if ctx.Err() != nil {
+		return nil
+	}
 	var issues []Issue
-	
-	// Find all assignments
+
 	ast.Inspect(file, func(n ast.Node) bool {
 		switch stmt := n.(type) {
 		case *ast.AssignStmt:
-			// Check if right side is a function call that might return an error
-			for _, rhs := range stmt.Rhs {
-				if call, ok := rhs.(*ast.CallExpr); ok {
-					// If the assignment has multiple left-hand values and more than one right-hand value,
-					// we need to check if the last one might be an error
-					if len(stmt.Lhs) > 1 && len(stmt.Rhs) == 1 {
-						// Check if the last left-hand value is being assigned to _
-						if len(stmt.Lhs) >= 2 {
-							lastLhs := stmt.Lhs[len(stmt.Lhs)-1]
-							if ident, ok := lastLhs.(*ast.Ident); ok && ident.Name == "_" {
-								// Error is being explicitly ignored
-								issues = append(issues, Issue{
-									Pos:      fset.Position(ident.Pos()),
-									Message:  "Error is explicitly ignored with _",
-									Severity: "warning",
-								})
-							}
+			for i, rhs := range stmt.Rhs {
+				call, ok := rhs.(*ast.CallExpr)
+				if !ok || isInfallibleWrite(info, call) {
+					continue
+				}
+				if len(stmt.Rhs) == 1 && len(stmt.Lhs) > 1 {
+					// A single call feeding every Lhs, e.g. v, err := f():
+					// match each discarded Lhs position against the call's
+					// result tuple by index.
+					tuple, ok := info.TypeOf(call).(*types.Tuple)
+					if !ok {
+						continue
+					}
+					for j := 0; j < tuple.Len() && j < len(stmt.Lhs); j++ {
+						if !isErrorType(tuple.At(j).Type()) {
+							continue
+						}
+						if ident, ok := stmt.Lhs[j].(*ast.Ident); ok && ident.Name == "_" {
+							issues = append(issues, Issue{
+								Pos:      fset.Position(ident.Pos()),
+								Message:  "Error is explicitly ignored with _",
+								Severity: "error",
+							})
 						}
 					}
+					continue
 				}
-			}
-		case *ast.ExprStmt:
-			// Check for function calls whose return values are completely ignored
-			if call, ok := stmt.X.(*ast.CallExpr); ok {
-				// Try to determine if the function might return an error
-				// This is a simplistic approach - in a real linter, we would use type information
-				if funcName, ok := getFunctionName(call); ok {
-					if strings.HasPrefix(funcName, "Create") ||
-					   strings.HasPrefix(funcName, "New") ||
-					   strings.HasPrefix(funcName, "Open") ||
-					   strings.HasPrefix(funcName, "Read") ||
-					   strings.HasPrefix(funcName, "Write") {
+				// One call per Lhs position, e.g. v, w := f(), g():
+				if i < len(stmt.Lhs) {
+					if ident, ok := stmt.Lhs[i].(*ast.Ident); ok && ident.Name == "_" && isErrorType(info.TypeOf(call)) {
 						issues = append(issues, Issue{
-							Pos:      fset.Position(call.Pos()),
-							Message:  fmt.Sprintf("Result of %s is ignored, but it might return an error", funcName),
-							Severity: "warning",
+							Pos:      fset.Position(ident.Pos()),
+							Message:  "Error is explicitly ignored with _",
+							Severity: "error",
 						})
 					}
 				}
 			}
+		case *ast.ExprStmt:
+			call, ok := stmt.X.(*ast.CallExpr)
+			if !ok || isInfallibleWrite(info, call) {
+				return true
+			}
+			if callReturnsError(info, call) {
+				funcName, _ := getFunctionName(call)
+				issues = append(issues, Issue{
+					Pos:      fset.Position(call.Pos()),
+					Message:  fmt.Sprintf("Result of %s is ignored, but it returns an error", funcName),
+					Severity: "error",
+				})
+			}
 		}
 		return true
 	})
-	
+
 	return issues
 }
 
@@ -180,88 +633,502 @@ func getFunctionName(call *ast.CallExpr) (string, bool) {
 	}
 }
 
-// Run the linter on a file
-func lintFile(filename string, rules []LintRule) ([]Issue, error) {
-	// Create file set for position information
-	fset := token.NewFileSet()
-	
-	// Parse the file
-	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+func init() {
+	registerRule(UnusedImportRule{})
+	registerRule(ErrorReturnRule{})
+}
+
+// LinterConfig is the schema for a linter.json (or .linter.toml) file,
+// modeled on gometalinter's config format: a single place to tune which
+// rules run, how noisy they are, and what to ignore, instead of spreading
+// that across flags on every invocation.
+type LinterConfig struct {
+	// Deadline bounds each file's lint pass, parsed via time.ParseDuration
+	// (e.g. "30s"). Empty means no deadline.
+	Deadline string `json:"deadline"`
+	// Enable restricts the active rule set to these names; empty means
+	// every registered rule runs (the tool's behavior before this config
+	// existed).
+	Enable []string `json:"enable"`
+	// Disable removes rules from the active set by name, applied after
+	// Enable.
+	Disable []string `json:"disable"`
+	// Severity overrides a rule's reported Severity, keyed by Name().
+	Severity map[string]string `json:"severity"`
+	// Exclude is a list of regexes matched against each formatted issue
+	// line before it's printed; a match drops the issue entirely.
+	Exclude []string `json:"exclude"`
+	// LinterOptions holds each rule's own config, keyed by Name() and
+	// decoded by that rule's Configure method.
+	LinterOptions map[string]json.RawMessage `json:"linteroptions"`
+}
+
+// loadLinterConfig reads a linter.json (or .linter.toml) config: JSON by
+// default, or the small TOML subset parseLinterConfigTOML understands when
+// path ends in ".toml". A missing file is not an error - it just means
+// every registered rule runs with its default severity and nothing
+// excluded, matching this tool's behavior before linter.json existed.
+func loadLinterConfig(path string) (*LinterConfig, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse file: %v", err)
+		if os.IsNotExist(err) {
+			return &LinterConfig{}, nil
+		}
+		return nil, err
+	}
+
+	if strings.HasSuffix(path, ".toml") {
+		return parseLinterConfigTOML(data), nil
+	}
+
+	var cfg LinterConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return &cfg, nil
+}
+
+// parseLinterConfigTOML parses the shape a linter.json config needs when
+// written as TOML: top-level deadline/enable/disable/exclude, a [severity]
+// table, and one [linteroptions.<rule>] table per rule with config. Like
+// parseLinterIgnoreTOML, it's hand-rolled rather than pulling in a TOML
+// library; unlike .linterignore, a rule's LinterOptions table here is
+// string-valued only (each value is re-marshaled to JSON for Configure),
+// since that's the only shape this tool's rules need so far.
+func parseLinterConfigTOML(data []byte) *LinterConfig {
+	cfg := &LinterConfig{
+		Severity:      map[string]string{},
+		LinterOptions: map[string]json.RawMessage{},
+	}
+
+	section := ""
+	var sectionOpts map[string]string
+
+	flushSection := func() {
+		if name, ok := cutPrefix(section, "linteroptions."); ok && len(sectionOpts) > 0 {
+			if raw, err := json.Marshal(sectionOpts); err == nil {
+				cfg.LinterOptions[name] = raw
+			}
+		}
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			flushSection()
+			section = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			sectionOpts = map[string]string{}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case section == "" && key == "deadline":
+			cfg.Deadline = strings.Trim(value, `"`)
+		case section == "" && key == "enable":
+			cfg.Enable = parseTOMLStringArray(value)
+		case section == "" && key == "disable":
+			cfg.Disable = parseTOMLStringArray(value)
+		case section == "" && key == "exclude":
+			cfg.Exclude = parseTOMLStringArray(value)
+		case section == "severity":
+			cfg.Severity[key] = strings.Trim(value, `"`)
+		case strings.HasPrefix(section, "linteroptions."):
+			sectionOpts[key] = strings.Trim(value, `"`)
+		}
+	}
+	flushSection()
+
+	return cfg
+}
+
+// activeRules resolves the registered rules cfg selects: every registered
+// rule by default, narrowed to cfg.Enable if non-empty, then with
+// cfg.Disable removed. Rules are returned in a fixed (name-sorted) order
+// so a run's output order doesn't depend on map iteration.
+func activeRules(cfg *LinterConfig) []LintRule {
+	names := make([]string, 0, len(ruleRegistry))
+	for name := range ruleRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	enabled := make(map[string]bool, len(names))
+	if len(cfg.Enable) > 0 {
+		for _, n := range cfg.Enable {
+			enabled[n] = true
+		}
+	} else {
+		for _, n := range names {
+			enabled[n] = true
+		}
+	}
+	for _, n := range cfg.Disable {
+		delete(enabled, n)
+	}
+
+	rules := make([]LintRule, 0, len(names))
+	for _, name := range names {
+		if enabled[name] {
+			rules = append(rules, ruleRegistry[name])
+		}
+	}
+	return rules
+}
+
+// compileExcludes compiles cfg's Exclude patterns up front, so a malformed
+// regex is reported once at startup instead of on the first issue it would
+// have matched.
+func compileExcludes(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// excludesAny reports whether line matches any of res.
+func excludesAny(res []*regexp.Regexp, line string) bool {
+	for _, re := range res {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// typedContext is the go/types view of one file, loaded on demand by
+// loadTyped for TypedRule's benefit.
+type typedContext struct {
+	fset *token.FileSet
+	file *ast.File
+	info *types.Info
+}
+
+// loadTyped type-checks the package containing filename via go/packages
+// and returns filename's own *ast.File plus that package's types.Info.
+// This is the expensive path (a full parse and typecheck of the package
+// and its imports) that lintFile only takes when some active rule actually
+// needs it.
+func loadTyped(filename string) (*typedContext, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+		Dir:  filepath.Dir(filename),
+	}
+	pkgs, err := packages.Load(cfg, "file="+filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package for %s: %v", filename, err)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no package found for %s", filename)
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, fmt.Errorf("failed to type-check package for %s: %v", filename, pkg.Errors[0])
+	}
+
+	abs, absErr := filepath.Abs(filename)
+	for _, f := range pkg.Syntax {
+		pos := pkg.Fset.Position(f.Pos())
+		if pos.Filename == filename || (absErr == nil && pos.Filename == abs) {
+			return &typedContext{fset: pkg.Fset, file: f, info: pkg.TypesInfo}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s not found in its own loaded package", filename)
+}
+
+// lintOptions bundles the runtime knobs lintFile needs beyond the rule
+// list itself: the -fix flag, the repo-level .linterignore rule set, and
+// the Severity/Deadline settings sourced from a loaded LinterConfig.
+type lintOptions struct {
+	fix         bool
+	repoIgnores Ignore
+	severity    map[string]string
+	deadline    time.Duration
+}
+
+// Run the linter on a file. When opts.fix is set, any issue whose rule
+// implements Fixer is resolved and rewritten to disk instead of being
+// reported; the cache entry for filename is invalidated so a later Parse
+// of it reads the rewritten file, while rules after the fixer in this call
+// keep using the same (already edited) AST. opts.repoIgnores is the shared
+// .linterignore rule set (nil if none is configured); it's consulted
+// alongside the file's own //lint:ignore and //lint:file-ignore
+// directives before an issue is reported. opts.severity overrides a
+// rule's reported Severity by its Name(), and opts.deadline (if nonzero)
+// bounds every rule's Check/CheckTyped call via ctx.
+func lintFile(filename string, rules []LintRule, opts lintOptions) ([]Issue, error) {
+	// Parse the file, via the shared in-process cache.
+	parsed, err := parseCache.Parse(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if opts.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.deadline)
+		defer cancel()
+	}
+
+	// Only pay for a full package typecheck if some active rule actually
+	// needs type info; plain AST rules never trigger it.
+	var typed *typedContext
+	for _, rule := range rules {
+		if _, ok := rule.(TypedRule); ok {
+			typed, err = loadTyped(filename)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	lineIgnores, fileIgnores := parseLintDirectives(parsed.Fset, parsed.File)
+	ignores := make([]Ignore, 0, len(lineIgnores)+len(fileIgnores)+1)
+	for _, ig := range lineIgnores {
+		ignores = append(ignores, ig)
 	}
-	
+	for _, ig := range fileIgnores {
+		ignores = append(ignores, ig)
+	}
+	if opts.repoIgnores != nil {
+		ignores = append(ignores, opts.repoIgnores)
+	}
+
 	// Apply all rules
 	var allIssues []Issue
 	for _, rule := range rules {
-		issues := rule.Check(fset, file)
-		allIssues = append(allIssues, issues...)
+		var issues []Issue
+		if typedRule, ok := rule.(TypedRule); ok {
+			issues = typedRule.CheckTyped(ctx, typed.fset, typed.file, typed.info)
+		} else {
+			issues = rule.Check(ctx, parsed.Fset, parsed.File)
+		}
+		for i := range issues {
+			issues[i].Check = rule.Name()
+			if sev, ok := opts.severity[rule.Name()]; ok {
+				issues[i].Severity = sev
+			}
+		}
+
+		if opts.fix && len(issues) > 0 {
+			if fixer, ok := rule.(Fixer); ok {
+				changed, err := fixer.Fix(parsed.Fset, parsed.File, filename)
+				if err != nil {
+					return allIssues, fmt.Errorf("failed to apply %s fix to %s: %v", rule.Name(), filename, err)
+				}
+				if changed {
+					parseCache.Invalidate(filename)
+					fmt.Printf("%s: fixed %d %s issue(s)\n", filename, len(issues), rule.Name())
+					continue
+				}
+			}
+		}
+
+		for _, issue := range issues {
+			if issueSuppressed(ignores, issue) {
+				continue
+			}
+			allIssues = append(allIssues, issue)
+		}
+	}
+
+	for _, ig := range lineIgnores {
+		if !ig.matched {
+			fmt.Fprintf(os.Stderr, "%s:%d: warning: //lint:ignore %s never matched an issue\n", ig.File, ig.Line, strings.Join(ig.Checks, ","))
+		}
 	}
-	
+
 	return allIssues, nil
 }
 
+// issueSuppressed reports whether any of ignores matches issue.
+func issueSuppressed(ignores []Ignore, issue Issue) bool {
+	for _, ig := range ignores {
+		if ig.Matches(issue.Pos, issue.Check) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintJob is one file dispatched to the worker pool in main, tagged with
+// its discovery order so results (which complete out of order across
+// workers) can be printed back in that same order.
+type lintJob struct {
+	index int
+	path  string
+}
+
+// lintResult is a lintJob's outcome, carrying its index along for the
+// reorder buffer in main.
+type lintResult struct {
+	index  int
+	path   string
+	issues []Issue
+	err    error
+}
+
 func main() {
 	// Parse command line flags
 	dir := flag.String("dir", ".", "Directory to lint")
 	recursive := flag.Bool("recursive", false, "Recursively lint subdirectories")
+	fix := flag.Bool("fix", false, "Automatically fix issues whose rule supports it (currently: unused-import)")
+	ignoreFile := flag.String("ignorefile", ".linterignore", "Repository-level ignore file (JSON, or TOML with a .toml extension) listing file/check glob suppressions")
+	configFile := flag.String("config", "linter.json", "Linter config file (JSON, or TOML with a .toml extension) setting enabled rules, severity overrides, exclusions, and per-rule options")
+	jobs := flag.Int("j", runtime.NumCPU(), "Number of files to lint concurrently")
 	flag.Parse()
-	
-	// Create lint rules
-	rules := []LintRule{
-		UnusedImportRule{},
-		ErrorReturnRule{},
-	}
-	
-	// Process files
-	var filesToLint []string
-	
-	walkFn := func(path string, info os.FileInfo, err error) error {
+
+	repoRules, err := loadLinterIgnore(*ignoreFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", *ignoreFile, err)
+		os.Exit(1)
+	}
+	var repoIgnores Ignore
+	if len(repoRules) > 0 {
+		repoIgnores = repoIgnoreSet(repoRules)
+	}
+
+	cfg, err := loadLinterConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", *configFile, err)
+		os.Exit(1)
+	}
+
+	var deadline time.Duration
+	if cfg.Deadline != "" {
+		deadline, err = time.ParseDuration(cfg.Deadline)
 		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			if !*recursive && path != *dir {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
-			filesToLint = append(filesToLint, path)
+			fmt.Fprintf(os.Stderr, "Error parsing deadline %q: %v\n", cfg.Deadline, err)
+			os.Exit(1)
 		}
-		return nil
 	}
-	
-	if err := filepath.Walk(*dir, walkFn); err != nil {
-		fmt.Fprintf(os.Stderr, "Error walking directory: %v\n", err)
+
+	excludes, err := compileExcludes(cfg.Exclude)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in %s: %v\n", *configFile, err)
 		os.Exit(1)
 	}
-	
-	// Lint each file
-	issueCount := 0
-	for _, file := range filesToLint {
-		issues, err := lintFile(file, rules)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error linting %s: %v\n", file, err)
-			continue
+
+	// Resolve the active rule set from the registry (Enable/Disable), then
+	// apply each rule's own LinterOptions entry, if it has one.
+	rules := activeRules(cfg)
+	for _, rule := range rules {
+		if err := rule.Configure(cfg.LinterOptions[rule.Name()]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error configuring rule %s: %v\n", rule.Name(), err)
+			os.Exit(1)
 		}
-		
-		for _, issue := range issues {
-			fmt.Printf("%s:%d:%d: %s: %s\n",
-				issue.Pos.Filename,
-				issue.Pos.Line,
-				issue.Pos.Column,
-				issue.Severity,
-				issue.Message)
-			issueCount++
+	}
+
+	opts := lintOptions{
+		fix:         *fix,
+		repoIgnores: repoIgnores,
+		severity:    cfg.Severity,
+		deadline:    deadline,
+	}
+
+	numWorkers := *jobs
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	// fastWalkGoFiles streams discovered files on its own goroutine while
+	// numWorkers worker goroutines lint them concurrently; parseCache (and
+	// packages.Load, for TypedRule) are both safe for this, so lintFile
+	// itself needs no locking here.
+	jobsCh := make(chan lintJob, numWorkers)
+	resultsCh := make(chan lintResult, numWorkers)
+
+	var workers sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobsCh {
+				issues, err := lintFile(job.path, rules, opts)
+				resultsCh <- lintResult{index: job.index, path: job.path, issues: issues, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobsCh)
+		index := 0
+		for path := range fastWalkGoFiles(*dir, *recursive) {
+			jobsCh <- lintJob{index: index, path: path}
+			index++
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(resultsCh)
+	}()
+
+	// Results complete in whatever order workers finish them, so they're
+	// buffered here by index and drained in discovery order - the order
+	// readDirFast returned directory entries in, which is not sorted -
+	// once the next expected index becomes available.
+	pending := make(map[int]lintResult)
+	next := 0
+	fileCount := 0
+	issueCount := 0
+	errorCount := 0
+	for result := range resultsCh {
+		pending[result.index] = result
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			fileCount++
+
+			if r.err != nil {
+				fmt.Fprintf(os.Stderr, "Error linting %s: %v\n", r.path, r.err)
+				continue
+			}
+			for _, issue := range r.issues {
+				line := fmt.Sprintf("%s:%d:%d: %s: [%s] %s",
+					issue.Pos.Filename,
+					issue.Pos.Line,
+					issue.Pos.Column,
+					issue.Severity,
+					issue.Check,
+					issue.Message)
+				if excludesAny(excludes, line) {
+					continue
+				}
+				fmt.Println(line)
+				issueCount++
+				if issue.Severity == "error" {
+					errorCount++
+				}
+			}
 		}
 	}
-	
+
 	// Print summary
-	fmt.Printf("\nLinted %d files, found %d issues\n", len(filesToLint), issueCount)
-	
-	// Return non-zero exit code if issues were found
-	if issueCount > 0 {
+	fmt.Printf("\nLinted %d files, found %d issues\n", fileCount, issueCount)
+
+	// Only an issue whose effective severity is "error" fails the run;
+	// warnings are reported but don't affect the exit code.
+	if errorCount > 0 {
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,274 @@
+// Package debugcheck implements the debugcheck analysis.Analyzer: it flags
+// debug print statements, TODO/FIXME comments, and assertion calls left in
+// production code. It is importable so it can run standalone (via
+// singlechecker, see ../analysis_tool.go) or alongside the other analyzers
+// in cmd/squiggle-vet's multichecker.
+package debugcheck
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer checks for debugging-related issues in code.
+var Analyzer = &analysis.Analyzer{
+	Name: "debugcheck",
+	Doc:  "checks for debugging-related issues in code",
+	Run:  run,
+	Requires: []*analysis.Analyzer{
+		inspect.Analyzer,
+	},
+}
+
+// Command line flags
+var (
+	checkDebugPrints = flag.Bool("debug-prints", true, "Check for debug print statements")
+	checkTodos       = flag.Bool("todos", true, "Check for TODO comments")
+	checkAsserts     = flag.Bool("asserts", true, "Check for assertions in production code")
+	verboseOutput    = flag.Bool("verbose", false, "Enable verbose output")
+)
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	// Get the inspector from the pass
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// Node filter for the inspector
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),     // For debug prints and assertions
+		(*ast.Comment)(nil),      // For TODO comments
+		(*ast.CommentGroup)(nil), // For TODO comments
+	}
+
+	// Visit the AST nodes
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			if *checkDebugPrints {
+				checkDebugPrint(pass, node)
+			}
+			if *checkAsserts {
+				checkAssertion(pass, node)
+			}
+		case *ast.Comment:
+			if *checkTodos {
+				checkTodoComment(pass, node)
+			}
+		}
+	})
+
+	return nil, nil
+}
+
+// Check for debug print statements
+func checkDebugPrint(pass *analysis.Pass, call *ast.CallExpr) {
+	// Check if it's a function call
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+
+	// Get the package and function name
+	pkgIdent, ok := fun.X.(*ast.Ident)
+	if !ok {
+		return
+	}
+
+	// Check for common debug print functions
+	pkgName := pkgIdent.Name
+	funcName := fun.Sel.Name
+
+	debugFuncs := map[string]map[string]bool{
+		"fmt": {
+			"Print":   true,
+			"Printf":  true,
+			"Println": true,
+		},
+		"log": {
+			"Print":   true,
+			"Printf":  true,
+			"Println": true,
+		},
+	}
+
+	if funcs, ok := debugFuncs[pkgName]; ok && funcs[funcName] {
+		// This is a potential debug print
+		// Check if it's in a function with "debug" in the name
+		inDebugFunc := false
+
+		// Walk up the AST to find the enclosing function
+		path, _ := astPath(pass.Files, call)
+		for _, p := range path {
+			if fn, ok := p.(*ast.FuncDecl); ok {
+				if strings.Contains(strings.ToLower(fn.Name.Name), "debug") {
+					inDebugFunc = true
+					break
+				}
+			}
+		}
+
+		// Report if it's not in a debug function
+		if !inDebugFunc {
+			fix := analysis.SuggestedFix{
+				Message: fmt.Sprintf("remove %s.%s statement", pkgName, funcName),
+			}
+			// Delete the whole enclosing ExprStmt, not just the call, so
+			// we don't leave a dangling expression statement behind.
+			if stmt := enclosingExprStmt(path, call); stmt != nil {
+				fix.TextEdits = []analysis.TextEdit{{
+					Pos:     stmt.Pos(),
+					End:     stmt.End(),
+					NewText: nil,
+				}}
+			}
+			pass.Report(analysis.Diagnostic{
+				Pos:            call.Pos(),
+				Message:        fmt.Sprintf("potentially unused debug print statement: %s.%s", pkgName, funcName),
+				SuggestedFixes: []analysis.SuggestedFix{fix},
+			})
+		}
+	}
+}
+
+// enclosingExprStmt returns the *ast.ExprStmt in path that wraps call, if
+// any (path is ordered outermost-first, as returned by astPath).
+func enclosingExprStmt(path []ast.Node, call *ast.CallExpr) *ast.ExprStmt {
+	for _, p := range path {
+		if stmt, ok := p.(*ast.ExprStmt); ok && stmt.X == ast.Expr(call) {
+			return stmt
+		}
+	}
+	return nil
+}
+
+// Check for TODO comments
+func checkTodoComment(pass *analysis.Pass, comment *ast.Comment) {
+	text := comment.Text
+
+	// Check for TODO/FIXME comments
+	if strings.Contains(strings.ToUpper(text), "TODO") || strings.Contains(strings.ToUpper(text), "FIXME") {
+		// Report all TODOs and FIXMEs
+		pass.Report(analysis.Diagnostic{
+			Pos:     comment.Pos(),
+			Message: fmt.Sprintf("found TODO/FIXME comment: %s", text),
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message: "remove comment",
+				TextEdits: []analysis.TextEdit{{
+					Pos:     comment.Pos(),
+					End:     comment.End(),
+					NewText: nil,
+				}},
+			}},
+		})
+	}
+}
+
+// Check for assertions in production code
+func checkAssertion(pass *analysis.Pass, call *ast.CallExpr) {
+	// Check if it's a function call
+	fun, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return
+	}
+
+	// Check for common assertion functions
+	assertFuncs := map[string]bool{
+		"assert":     true,
+		"assertThat": true,
+		"require":    true,
+		"check":      true,
+	}
+
+	if assertFuncs[fun.Name] {
+		// This is a potential assertion. Propose turning it into an
+		// explicit panic so the check survives in production instead of
+		// silently depending on an undeclared "assert" helper.
+		var args bytes.Buffer
+		for i, arg := range call.Args {
+			if i > 0 {
+				args.WriteString(", ")
+			}
+			format.Node(&args, token.NewFileSet(), arg)
+		}
+		replacement := fmt.Sprintf("if !(%s) { panic(%q) }", args.String(), fmt.Sprintf("%s failed", fun.Name))
+
+		pass.Report(analysis.Diagnostic{
+			Pos:     call.Pos(),
+			Message: fmt.Sprintf("assertion found in production code: %s", fun.Name),
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message: "replace with explicit panic",
+				TextEdits: []analysis.TextEdit{{
+					Pos:     call.Pos(),
+					End:     call.End(),
+					NewText: []byte(replacement),
+				}},
+			}},
+		})
+	}
+}
+
+// parentCache memoizes the parent-pointer map for each *ast.File so that
+// repeated diagnostics against the same file (there's one astPath call per
+// debug-print candidate) don't each re-walk the whole tree from scratch.
+// Building the map is O(tree) once per file; every astPath call after that
+// is O(depth).
+var parentCache sync.Map // map[*ast.File]map[ast.Node]ast.Node
+
+func parentsOf(f *ast.File) map[ast.Node]ast.Node {
+	if cached, ok := parentCache.Load(f); ok {
+		return cached.(map[ast.Node]ast.Node)
+	}
+
+	parents := make(map[ast.Node]ast.Node)
+	var stack []ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			return true
+		}
+		if len(stack) > 0 {
+			parents[n] = stack[len(stack)-1]
+		}
+		stack = append(stack, n)
+		return true
+	})
+
+	// LoadOrStore in case a concurrent analyzer run raced us; either way
+	// every caller ends up using the same map instance.
+	actual, _ := parentCache.LoadOrStore(f, parents)
+	return actual.(map[ast.Node]ast.Node)
+}
+
+// astPath returns target's ancestor chain, outermost (the *ast.File) first
+// and target itself last, within whichever of files contains it.
+func astPath(files []*ast.File, target ast.Node) ([]ast.Node, bool) {
+	for _, f := range files {
+		parents := parentsOf(f)
+		if target != ast.Node(f) {
+			if _, ok := parents[target]; !ok {
+				continue
+			}
+		}
+
+		var path []ast.Node
+		for n := target; n != nil; n = parents[n] {
+			path = append([]ast.Node{n}, path...)
+			if n == ast.Node(f) {
+				break
+			}
+		}
+		return path, true
+	}
+	return nil, false
+}
@@ -0,0 +1,17 @@
+package debugcheck_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/KindMan0011/squiggleconf-2025/tutorials/go/debugging/ast/debugcheck"
+)
+
+// TestAnalyzer runs the debugcheck analyzer against testdata/src/a, checking
+// both the reported diagnostics (via the "// want" comments) and the
+// SuggestedFixes (via the accompanying .golden files) for all three checks:
+// debug prints, TODO/FIXME comments, and assertions.
+func TestAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), debugcheck.Analyzer, "a")
+}
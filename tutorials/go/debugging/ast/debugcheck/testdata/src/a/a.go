@@ -0,0 +1,15 @@
+// Package a is a fixture for the debugcheck analyzer's golden-file tests.
+package a
+
+import "fmt"
+
+func assert(cond bool) {}
+
+func doWork(x int) {
+	fmt.Println("debug", x) // want `potentially unused debug print statement: fmt\.Println`
+
+	assert(x > 0) // want `assertion found in production code: assert`
+}
+
+// TODO: remove this before shipping // want `found TODO/FIXME comment`
+func helper() {}
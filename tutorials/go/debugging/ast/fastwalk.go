@@ -0,0 +1,50 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// dirEntry is the minimal piece of a directory entry fastWalkGoFiles
+// needs: its name and whether it's itself a directory. readDirFast
+// implementations fill this in straight from the raw dirent where
+// possible, to avoid an Lstat per entry.
+type dirEntry struct {
+	name  string
+	isDir bool
+}
+
+// fastWalkGoFiles discovers every .go file under root (except *_test.go),
+// listing each directory with readDirFast instead of filepath.Walk, so
+// entries whose type the directory read already reports need no Lstat.
+// readDirFast is implemented per-platform: see fastwalk_linux.go,
+// fastwalk_bsd.go, and fastwalk_fallback.go. The scan runs on one
+// goroutine and streams paths on the returned channel as they're found,
+// closing it once the whole tree (or just root, if recursive is false) has
+// been scanned.
+func fastWalkGoFiles(root string, recursive bool) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		walkDir(root, recursive, out)
+	}()
+	return out
+}
+
+func walkDir(dir string, recursive bool, out chan<- string) {
+	entries, err := readDirFast(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.isDir {
+			if recursive {
+				walkDir(filepath.Join(dir, e.name), recursive, out)
+			}
+			continue
+		}
+		if strings.HasSuffix(e.name, ".go") && !strings.HasSuffix(e.name, "_test.go") {
+			out <- filepath.Join(dir, e.name)
+		}
+	}
+}
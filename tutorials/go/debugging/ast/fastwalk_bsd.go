@@ -0,0 +1,79 @@
+//go:build darwin || freebsd || netbsd || openbsd || dragonfly
+
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"syscall"
+)
+
+// readDirFast lists dir's entries via a raw directory-entry scan (through
+// syscall.ReadDirent), as readDirFast does on Linux, but against the
+// BSD/Darwin struct dirent layout: d_fileno, d_reclen, d_type, d_namlen,
+// then the name - reclen and the type byte sit at different offsets than
+// linux_dirent64, and the name is length-prefixed (d_namlen) rather than
+// always NUL-terminated within reclen.
+func readDirFast(dir string) ([]dirEntry, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	const (
+		dtDir = 4
+		dtReg = 8
+	)
+
+	var entries []dirEntry
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := syscall.ReadDirent(int(f.Fd()), buf)
+		if err != nil {
+			return entries, err
+		}
+		if n == 0 {
+			return entries, nil
+		}
+
+		pos := 0
+		for pos < n {
+			rec := buf[pos:n]
+			if len(rec) < 8 {
+				break
+			}
+			reclen := int(binary.LittleEndian.Uint16(rec[4:6]))
+			if reclen == 0 || reclen > len(rec) {
+				break
+			}
+
+			typ := rec[6]
+			namlen := int(rec[7])
+			nameStart := 8
+			if nameStart+namlen > len(rec) {
+				pos += reclen
+				continue
+			}
+			name := string(rec[nameStart : nameStart+namlen])
+			pos += reclen
+
+			if name == "." || name == ".." {
+				continue
+			}
+
+			switch typ {
+			case dtDir:
+				entries = append(entries, dirEntry{name: name, isDir: true})
+			case dtReg:
+				entries = append(entries, dirEntry{name: name, isDir: false})
+			default:
+				fi, err := os.Lstat(dir + "/" + name)
+				if err != nil {
+					continue
+				}
+				entries = append(entries, dirEntry{name: name, isDir: fi.IsDir()})
+			}
+		}
+	}
+}
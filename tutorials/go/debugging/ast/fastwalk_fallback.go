@@ -0,0 +1,23 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+
+package main
+
+import "os"
+
+// readDirFast is the portable fallback for platforms without a raw
+// dirent scan here (Windows, Solaris, Plan 9, js/wasm, ...): it uses
+// os.ReadDir, which already reports each entry's type from the directory
+// read itself on most of these platforms, so it still avoids a separate
+// Lstat in the common case without needing a platform-specific getdents
+// layout.
+func readDirFast(dir string) ([]dirEntry, error) {
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]dirEntry, 0, len(des))
+	for _, de := range des {
+		entries = append(entries, dirEntry{name: de.Name(), isDir: de.IsDir()})
+	}
+	return entries, nil
+}
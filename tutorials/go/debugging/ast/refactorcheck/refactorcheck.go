@@ -0,0 +1,143 @@
+// Package refactorcheck wraps the rename-fn, rename-type, and add-param
+// refactorings (see ../refactoring_tool.go) as analysis.Analyzer values
+// that never write files themselves. rename-fn and rename-type report
+// "would-change" diagnostics with a SuggestedFix, which a driver's -fix
+// mode can apply; add-param reports diagnostics only, with no
+// SuggestedFix. This lets the same logic run uniformly under `go vet -vettool=squiggle-vet` or any other editor
+// integration that understands the analysis framework.
+package refactorcheck
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// newRenameAnalyzer builds an Analyzer that flags every identifier in the
+// package under analysis which resolves to an object named -old of the
+// given kind, proposing a rename to -new.
+func newRenameAnalyzer(name, doc string, match func(types.Object) bool) *analysis.Analyzer {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	oldName := fs.String("old", "", "identifier to rename")
+	newName := fs.String("new", "", "replacement name")
+
+	a := &analysis.Analyzer{
+		Name:  name,
+		Doc:   doc,
+		Flags: *fs,
+		Requires: []*analysis.Analyzer{
+			inspect.Analyzer,
+		},
+	}
+	a.Run = func(pass *analysis.Pass) (interface{}, error) {
+		if *oldName == "" || *newName == "" {
+			return nil, nil
+		}
+		reportRenames(pass, *oldName, *newName, match)
+		return nil, nil
+	}
+	return a
+}
+
+func reportRenames(pass *analysis.Pass, oldName, newName string, match func(types.Object) bool) {
+	report := func(ident *ast.Ident) {
+		pass.Report(analysis.Diagnostic{
+			Pos:     ident.Pos(),
+			Message: fmt.Sprintf("rename-fn/rename-type would rename %q to %q here", oldName, newName),
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message: fmt.Sprintf("rename to %s", newName),
+				TextEdits: []analysis.TextEdit{{
+					Pos:     ident.Pos(),
+					End:     ident.End(),
+					NewText: []byte(newName),
+				}},
+			}},
+		})
+	}
+	for ident, obj := range pass.TypesInfo.Defs {
+		if obj != nil && obj.Name() == oldName && match(obj) {
+			report(ident)
+		}
+	}
+	for ident, obj := range pass.TypesInfo.Uses {
+		if obj != nil && obj.Name() == oldName && match(obj) {
+			report(ident)
+		}
+	}
+}
+
+// RenameFuncAnalyzer mirrors refactoring_tool's -rename-fn flag.
+var RenameFuncAnalyzer = newRenameAnalyzer(
+	"rename-fn",
+	"flags identifiers that -rename-fn would change (read-only preview)",
+	func(obj types.Object) bool { _, ok := obj.(*types.Func); return ok },
+)
+
+// RenameTypeAnalyzer mirrors refactoring_tool's -rename-type flag.
+var RenameTypeAnalyzer = newRenameAnalyzer(
+	"rename-type",
+	"flags identifiers that -rename-type would change (read-only preview)",
+	func(obj types.Object) bool { _, ok := obj.(*types.TypeName); return ok },
+)
+
+// AddParamAnalyzer mirrors refactoring_tool's -add-param flag: it flags
+// every function declaration and call whose name matches -func (only the
+// method name, for "Type.Method"), without touching any files itself.
+// Matching is by name, not by resolved object, so it can flag sites
+// refactoring_tool would leave alone; interface method declarations are
+// not flagged.
+var AddParamAnalyzer = newAddParamAnalyzer()
+
+func newAddParamAnalyzer() *analysis.Analyzer {
+	fs := flag.NewFlagSet("add-param", flag.ExitOnError)
+	funcName := fs.String("func", "", "function or Type.Method to add a parameter to")
+	paramName := fs.String("name", "", "parameter name")
+	paramType := fs.String("type", "", "parameter type")
+
+	a := &analysis.Analyzer{
+		Name:  "add-param",
+		Doc:   "flags the declaration and call sites that -add-param would change (read-only preview)",
+		Flags: *fs,
+		Requires: []*analysis.Analyzer{
+			inspect.Analyzer,
+		},
+	}
+	a.Run = func(pass *analysis.Pass) (interface{}, error) {
+		if *funcName == "" {
+			return nil, nil
+		}
+		target := *funcName
+		if _, method, ok := strings.Cut(*funcName, "."); ok {
+			target = method
+		}
+
+		inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+		inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil), (*ast.CallExpr)(nil)}, func(n ast.Node) {
+			switch node := n.(type) {
+			case *ast.FuncDecl:
+				if node.Name.Name == target {
+					pass.Reportf(node.Pos(), "add-param would add %s %s to %s", *paramName, *paramType, node.Name.Name)
+				}
+			case *ast.CallExpr:
+				switch fun := node.Fun.(type) {
+				case *ast.Ident:
+					if fun.Name == target {
+						pass.Reportf(node.Pos(), "add-param would pass a default argument for %s here", *paramName)
+					}
+				case *ast.SelectorExpr:
+					if fun.Sel.Name == target {
+						pass.Reportf(node.Pos(), "add-param would pass a default argument for %s here", *paramName)
+					}
+				}
+			}
+		})
+		return nil, nil
+	}
+	return a
+}
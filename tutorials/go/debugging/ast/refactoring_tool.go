@@ -8,29 +8,234 @@ import (
 	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"os"
-	"path/filepath"
 	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/KindMan0011/squiggleconf-2025/tutorials/go/debugging/ast/unused"
 )
 
 // Refactoring options
 var (
-	inputDir      = flag.String("dir", ".", "Directory to process")
-	recursive     = flag.Bool("recursive", false, "Process subdirectories recursively")
-	renameFn      = flag.String("rename-fn", "", "Rename function (old:new)")
-	renameType    = flag.String("rename-type", "", "Rename type (old:new)")
-	renameVar     = flag.String("rename-var", "", "Rename variable (old:new)")
-	extractMethod = flag.String("extract-method", "", "Extract method (file:line:name)")
-	addParam      = flag.String("add-param", "", "Add parameter (function:name:type)")
-	write         = flag.Bool("write", false, "Write changes to files")
+	inputDir     = flag.String("dir", ".", "Directory or go/packages pattern to process")
+	recursive    = flag.Bool("recursive", false, "Process subdirectories recursively (expands the pattern to '...')")
+	renameFn     = flag.String("rename-fn", "", "Rename function (old:new)")
+	renameType   = flag.String("rename-type", "", "Rename type (old:new)")
+	renameVar    = flag.String("rename-var", "", "Rename variable (old:new)")
+	addParam     = flag.String("add-param", "", "Add parameter (function:name:type, function may be 'Type.Method')")
+	defaultArg   = flag.String("default-arg", "", "Default argument expression to pass at existing call sites of -add-param")
+	removeUnused = flag.Bool("remove-unused", false, "Remove declarations unreachable from the analysis roots (see -whole-program)")
+	wholeProgram = flag.Bool("whole-program", false, "With -remove-unused, only root main/Test* (flags unused exported API too)")
+	write        = flag.Bool("write", false, "Write changes to files")
 )
 
-// Refactoring interface
+// loadMode requests everything a type-aware rename needs: syntax trees,
+// fully resolved types, and the Defs/Uses/Selections maps that tie
+// identifiers back to types.Object.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedImports | packages.NeedDeps | packages.NeedTypes |
+	packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo
+
+// Refactoring is a whole-program rewrite driven by go/types rather than
+// positional AST matching. Apply resolves its target against the loaded
+// package graph and rewrites every identifier that denotes it, returning
+// the set of files it touched (so the caller can format and write them).
 type Refactoring interface {
-	Apply(fset *token.FileSet, file *ast.File) (bool, error)
+	Apply(pkgs []*packages.Package) (map[*ast.File]*packages.Package, error)
 	Description() string
 }
 
+// ObjKind restricts a lookup to a particular category of types.Object so
+// "Foo" the function and "Foo" the type in the same scope aren't confused.
+type ObjKind int
+
+const (
+	ObjFunc ObjKind = iota
+	ObjType
+	ObjVar
+)
+
+func (k ObjKind) String() string {
+	switch k {
+	case ObjFunc:
+		return "function"
+	case ObjType:
+		return "type"
+	case ObjVar:
+		return "variable"
+	default:
+		return "object"
+	}
+}
+
+func (k ObjKind) matches(obj types.Object) bool {
+	switch k {
+	case ObjFunc:
+		_, ok := obj.(*types.Func)
+		return ok
+	case ObjType:
+		_, ok := obj.(*types.TypeName)
+		return ok
+	case ObjVar:
+		_, ok := obj.(*types.Var)
+		return ok
+	default:
+		return false
+	}
+}
+
+// findObject locates the first package-scope object named name with the
+// given kind across the loaded package graph.
+func findObject(pkgs []*packages.Package, name string, kind ObjKind) (types.Object, *packages.Package, error) {
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		if obj := pkg.Types.Scope().Lookup(name); obj != nil && kind.matches(obj) {
+			return obj, pkg, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no %s named %q found in the loaded packages", kind, name)
+}
+
+// renameObject renames every identifier across pkgs that denotes obj,
+// including method/field selectors reached through go/types' Selections
+// map (so method receivers, plain selector expressions, and embedded
+// field promotions are all covered, not just bare Defs/Uses).
+//
+// Before rewriting anything, it walks every use site's innermost scope
+// and refuses the rename if newName already resolves to something else
+// there, or if the rename would flip exported/unexported visibility.
+func renameObject(pkgs []*packages.Package, obj types.Object, newName string) (map[*ast.File]*packages.Package, error) {
+	if obj.Name() == newName {
+		return nil, fmt.Errorf("%s is already named %q", obj, newName)
+	}
+	if token.IsExported(obj.Name()) != token.IsExported(newName) {
+		return nil, fmt.Errorf("rename of %s to %q would change exported/unexported visibility", obj, newName)
+	}
+
+	type hit struct {
+		pkg   *packages.Package
+		ident *ast.Ident
+	}
+	var hits []hit
+
+	for _, pkg := range pkgs {
+		info := pkg.TypesInfo
+		if info == nil {
+			continue
+		}
+		for ident, o := range info.Defs {
+			if o == obj {
+				hits = append(hits, hit{pkg, ident})
+			}
+		}
+		for ident, o := range info.Uses {
+			if o == obj {
+				hits = append(hits, hit{pkg, ident})
+			}
+		}
+		for sel, selection := range info.Selections {
+			if selection.Obj() == obj {
+				hits = append(hits, hit{pkg, sel.Sel})
+			}
+		}
+	}
+
+	if len(hits) == 0 {
+		return nil, fmt.Errorf("%s has no references in the loaded packages", obj)
+	}
+
+	// Conflict detection: newName must not already be visible (and
+	// denote something else) at any use site's scope.
+	for _, h := range hits {
+		scope := h.pkg.Types.Scope().Innermost(h.ident.Pos())
+		if scope == nil {
+			continue
+		}
+		if _, existing := scope.LookupParent(newName, h.ident.Pos()); existing != nil && existing != obj {
+			return nil, fmt.Errorf("rename of %s to %q conflicts with %s visible at %v",
+				obj, newName, existing, h.pkg.Fset.Position(h.ident.Pos()))
+		}
+	}
+
+	touched := make(map[*ast.File]*packages.Package)
+	renamedFields := make(map[*ast.Field]bool)
+	for _, h := range hits {
+		oldName := h.ident.Name
+		h.ident.Name = newName
+		touched[enclosingFile(h.pkg, h.ident)] = h.pkg
+
+		// If this identifier is a struct field's own name, a same-valued
+		// tag (e.g. `db:"OldName"`) is almost certainly referring to it;
+		// keep it in sync rather than leaving a stale reference behind.
+		if field, ok := fieldForIdent(h.pkg, h.ident); ok && !renamedFields[field] {
+			if updateFieldTag(field, oldName, newName) {
+				renamedFields[field] = true
+			}
+		}
+	}
+
+	return touched, nil
+}
+
+// enclosingFile returns the *ast.File containing ident within pkg.
+func enclosingFile(pkg *packages.Package, ident *ast.Ident) *ast.File {
+	pos := ident.Pos()
+	for _, f := range pkg.Syntax {
+		if f.FileStart <= pos && pos < f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
+
+// fieldForIdent reports whether ident is (one of) the Names of an
+// *ast.Field, returning that field.
+func fieldForIdent(pkg *packages.Package, ident *ast.Ident) (*ast.Field, bool) {
+	for _, f := range pkg.Syntax {
+		var found *ast.Field
+		ast.Inspect(f, func(n ast.Node) bool {
+			if found != nil {
+				return false
+			}
+			if field, ok := n.(*ast.Field); ok {
+				for _, name := range field.Names {
+					if name == ident {
+						found = field
+						return false
+					}
+				}
+			}
+			return true
+		})
+		if found != nil {
+			return found, true
+		}
+	}
+	return nil, false
+}
+
+// updateFieldTag rewrites any tag value that equals oldName to newName.
+func updateFieldTag(field *ast.Field, oldName, newName string) bool {
+	if field.Tag == nil {
+		return false
+	}
+	raw := strings.Trim(field.Tag.Value, "`")
+	if !strings.Contains(raw, oldName) {
+		return false
+	}
+	updated := strings.ReplaceAll(raw, `"`+oldName+`"`, `"`+newName+`"`)
+	updated = strings.ReplaceAll(updated, ":"+oldName+",", ":"+newName+",")
+	if updated == raw {
+		return false
+	}
+	field.Tag.Value = "`" + updated + "`"
+	return true
+}
+
 // RenameFunction refactoring
 type RenameFunction struct {
 	OldName string
@@ -41,22 +246,12 @@ func (r RenameFunction) Description() string {
 	return fmt.Sprintf("Rename function from '%s' to '%s'", r.OldName, r.NewName)
 }
 
-func (r RenameFunction) Apply(fset *token.FileSet, file *ast.File) (bool, error) {
-	changed := false
-	
-	// Visit all identifiers
-	ast.Inspect(file, func(n ast.Node) bool {
-		if ident, ok := n.(*ast.Ident); ok && ident.Name == r.OldName {
-			// Check if this is a function declaration or a function call
-			if isFunctionIdent(ident) {
-				ident.Name = r.NewName
-				changed = true
-			}
-		}
-		return true
-	})
-	
-	return changed, nil
+func (r RenameFunction) Apply(pkgs []*packages.Package) (map[*ast.File]*packages.Package, error) {
+	obj, _, err := findObject(pkgs, r.OldName, ObjFunc)
+	if err != nil {
+		return nil, err
+	}
+	return renameObject(pkgs, obj, r.NewName)
 }
 
 // RenameType refactoring
@@ -69,22 +264,12 @@ func (r RenameType) Description() string {
 	return fmt.Sprintf("Rename type from '%s' to '%s'", r.OldName, r.NewName)
 }
 
-func (r RenameType) Apply(fset *token.FileSet, file *ast.File) (bool, error) {
-	changed := false
-	
-	// Visit all identifiers
-	ast.Inspect(file, func(n ast.Node) bool {
-		if ident, ok := n.(*ast.Ident); ok && ident.Name == r.OldName {
-			// Check if this is a type identifier
-			if isTypeIdent(ident) {
-				ident.Name = r.NewName
-				changed = true
-			}
-		}
-		return true
-	})
-	
-	return changed, nil
+func (r RenameType) Apply(pkgs []*packages.Package) (map[*ast.File]*packages.Package, error) {
+	obj, _, err := findObject(pkgs, r.OldName, ObjType)
+	if err != nil {
+		return nil, err
+	}
+	return renameObject(pkgs, obj, r.NewName)
 }
 
 // RenameVariable refactoring
@@ -97,185 +282,447 @@ func (r RenameVariable) Description() string {
 	return fmt.Sprintf("Rename variable from '%s' to '%s'", r.OldName, r.NewName)
 }
 
-func (r RenameVariable) Apply(fset *token.FileSet, file *ast.File) (bool, error) {
-	changed := false
-	
-	// Visit all identifiers
-	ast.Inspect(file, func(n ast.Node) bool {
-		if ident, ok := n.(*ast.Ident); ok && ident.Name == r.OldName {
-			// Check if this is a variable identifier
-			if isVarIdent(ident) {
-				ident.Name = r.NewName
-				changed = true
-			}
-		}
-		return true
-	})
-	
-	return changed, nil
+func (r RenameVariable) Apply(pkgs []*packages.Package) (map[*ast.File]*packages.Package, error) {
+	obj, _, err := findObject(pkgs, r.OldName, ObjVar)
+	if err != nil {
+		return nil, err
+	}
+	return renameObject(pkgs, obj, r.NewName)
 }
 
-// AddParameter refactoring
+// AddParameter refactoring. FunctionName is either a bare function name
+// ("Process") or "Type.Method" for a method. Every call site across the
+// loaded packages is rewritten to pass DefaultArg for the new parameter,
+// including call sites that go through an interface the receiver type
+// satisfies.
+//
+// Known limitation: calls made through a method value bound to a local
+// variable (f := obj.Method; f(...)) resolve to that variable rather than
+// to the method itself, so they are not currently rewritten.
 type AddParameter struct {
 	FunctionName string
 	ParamName    string
 	ParamType    string
+	DefaultArg   string
 }
 
 func (r AddParameter) Description() string {
-	return fmt.Sprintf("Add parameter '%s %s' to function '%s'", r.ParamName, r.ParamType, r.FunctionName)
-}
-
-func (r AddParameter) Apply(fset *token.FileSet, file *ast.File) (bool, error) {
-	changed := false
-	
-	// Visit all function declarations
-	ast.Inspect(file, func(n ast.Node) bool {
-		if funcDecl, ok := n.(*ast.FuncDecl); ok && funcDecl.Name.Name == r.FunctionName {
-			// Add parameter to the function declaration
-			newParam := &ast.Field{
-				Names: []*ast.Ident{ast.NewIdent(r.ParamName)},
-				Type:  ast.NewIdent(r.ParamType),
+	return fmt.Sprintf("Add parameter '%s %s' to function '%s' (default arg at call sites: %s)",
+		r.ParamName, r.ParamType, r.FunctionName, r.DefaultArg)
+}
+
+// resolveFuncTarget resolves FunctionName to a *types.Func, handling both
+// free functions and "Type.Method" method references.
+func resolveFuncTarget(pkgs []*packages.Package, name string) (*types.Func, error) {
+	if recv, method, ok := strings.Cut(name, "."); ok {
+		for _, pkg := range pkgs {
+			if pkg.Types == nil {
+				continue
 			}
-			
-			if funcDecl.Type.Params == nil {
-				funcDecl.Type.Params = &ast.FieldList{}
+			tn, ok := pkg.Types.Scope().Lookup(recv).(*types.TypeName)
+			if !ok {
+				continue
 			}
-			
-			funcDecl.Type.Params.List = append(funcDecl.Type.Params.List, newParam)
-			changed = true
-			
-			// Now we should also update all calls to this function, but that's more complex
-			// and would require type checking to be done properly
-		}
-		return true
-	})
-	
-	return changed, nil
-}
-
-// Helper functions to check identifier types
-func isFunctionIdent(ident *ast.Ident) bool {
-	// Check if the identifier is a function name
-	if ident.Obj == nil {
-		return false
-	}
-	
-	switch ident.Obj.Kind {
-	case ast.Fun:
-		return true
-	default:
-		// Check parent node
-		switch parent := ident.Obj.Decl.(type) {
-		case *ast.FuncDecl:
-			return ident == parent.Name
-		case *ast.CallExpr:
-			switch fun := parent.Fun.(type) {
-			case *ast.Ident:
-				return ident == fun
-			case *ast.SelectorExpr:
-				return ident == fun.Sel
+			named, ok := tn.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				if m := named.Method(i); m.Name() == method {
+					return m, nil
+				}
 			}
 		}
+		return nil, fmt.Errorf("no method %s.%s found in the loaded packages", recv, method)
 	}
-	
-	return false
+
+	obj, _, err := findObject(pkgs, name, ObjFunc)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a function", name)
+	}
+	return fn, nil
 }
 
-func isTypeIdent(ident *ast.Ident) bool {
-	// Check if the identifier is a type name
-	if ident.Obj == nil {
-		return false
+// interfaceFamily returns every *types.Func that the call-site rewrite must
+// also treat as "fn": the abstract method on any interface fn's receiver
+// satisfies, and the same-named method on every other concrete type
+// declared in that interface's own package that also satisfies it. This is
+// what lets a call through an interface value pick up the new parameter
+// too. Types in other packages that satisfy the interface are not searched.
+func interfaceFamily(pkgs []*packages.Package, fn *types.Func) []*types.Func {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return nil
 	}
-	
-	switch ident.Obj.Kind {
-	case ast.Typ:
-		return true
-	default:
-		// Check parent node
-		switch parent := ident.Obj.Decl.(type) {
-		case *ast.TypeSpec:
-			return ident == parent.Name
+	recvType := sig.Recv().Type()
+
+	var family []*types.Func
+	seen := map[*types.Func]bool{fn: true}
+	add := func(m *types.Func) {
+		if m != nil && !seen[m] {
+			seen[m] = true
+			family = append(family, m)
 		}
 	}
-	
-	return false
+
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			iface, ok := tn.Type().Underlying().(*types.Interface)
+			if !ok {
+				continue
+			}
+			if !types.Implements(recvType, iface) && !types.Implements(types.NewPointer(recvType), iface) {
+				continue
+			}
+			for i := 0; i < iface.NumMethods(); i++ {
+				if m := iface.Method(i); m.Name() == fn.Name() {
+					add(m)
+				}
+			}
+			// Every other concrete type satisfying the same interface
+			// needs its sibling method updated too.
+			for _, otherName := range scope.Names() {
+				otherTN, ok := scope.Lookup(otherName).(*types.TypeName)
+				if !ok {
+					continue
+				}
+				named, ok := otherTN.Type().(*types.Named)
+				if !ok || named == recvType {
+					continue
+				}
+				if !types.Implements(named, iface) && !types.Implements(types.NewPointer(named), iface) {
+					continue
+				}
+				for i := 0; i < named.NumMethods(); i++ {
+					if m := named.Method(i); m.Name() == fn.Name() {
+						add(m)
+					}
+				}
+			}
+		}
+	}
+	return family
 }
 
-func isVarIdent(ident *ast.Ident) bool {
-	// Check if the identifier is a variable name
-	if ident.Obj == nil {
-		return false
+// addParamToDecl appends the new parameter to a func or method declaration
+// (or to an interface method's signature, found via its enclosing
+// InterfaceType field list) in every syntax tree of pkg.
+func addParamToDecl(pkg *packages.Package, target *types.Func, paramName, paramType string) *ast.File {
+	var touched *ast.File
+	newParam := func() *ast.Field {
+		return &ast.Field{
+			Names: []*ast.Ident{ast.NewIdent(paramName)},
+			Type:  ast.NewIdent(paramType),
+		}
 	}
-	
-	switch ident.Obj.Kind {
-	case ast.Var:
-		return true
-	default:
-		// Check parent node
-		switch ident.Obj.Decl.(type) {
-		case *ast.AssignStmt, *ast.ValueSpec, *ast.Field:
+
+	for _, f := range pkg.Syntax {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch decl := n.(type) {
+			case *ast.FuncDecl:
+				if pkg.TypesInfo.Defs[decl.Name] != types.Object(target) {
+					return true
+				}
+				if decl.Type.Params == nil {
+					decl.Type.Params = &ast.FieldList{}
+				}
+				decl.Type.Params.List = append(decl.Type.Params.List, newParam())
+				touched = f
+				return false
+			case *ast.InterfaceType:
+				for _, m := range decl.Methods.List {
+					if len(m.Names) == 0 || m.Names[0].Name != target.Name() {
+						continue
+					}
+					if pkg.TypesInfo.Defs[m.Names[0]] != types.Object(target) {
+						continue
+					}
+					ft, ok := m.Type.(*ast.FuncType)
+					if !ok {
+						continue
+					}
+					if ft.Params == nil {
+						ft.Params = &ast.FieldList{}
+					}
+					ft.Params.List = append(ft.Params.List, newParam())
+					touched = f
+				}
+			}
 			return true
+		})
+	}
+	return touched
+}
+
+// rewriteCallSites appends DefaultArg to every call across pkgs that
+// resolves (directly, or through a selector/method expression) to one of
+// the funcs in targets.
+func rewriteCallSites(pkgs []*packages.Package, targets map[*types.Func]bool, defaultArg string) (map[*ast.File]*packages.Package, error) {
+	argExpr, err := parser.ParseExpr(defaultArg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -default-arg %q: %v", defaultArg, err)
+	}
+
+	touched := make(map[*ast.File]*packages.Package)
+	for _, pkg := range pkgs {
+		info := pkg.TypesInfo
+		if info == nil {
+			continue
+		}
+		for _, f := range pkg.Syntax {
+			ast.Inspect(f, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+
+				var fn *types.Func
+				switch fun := call.Fun.(type) {
+				case *ast.Ident:
+					fn, _ = info.Uses[fun].(*types.Func)
+				case *ast.SelectorExpr:
+					if sel, ok := info.Selections[fun]; ok {
+						fn, _ = sel.Obj().(*types.Func)
+					} else {
+						fn, _ = info.Uses[fun.Sel].(*types.Func)
+					}
+				}
+				if fn == nil || !targets[fn] {
+					return true
+				}
+
+				call.Args = append(call.Args, copyExpr(argExpr))
+				touched[f] = pkg
+				return true
+			})
 		}
 	}
-	
-	return false
+	return touched, nil
+}
+
+// copyExpr returns a fresh copy of a parsed argument expression so the
+// same *ast.Expr literal isn't shared (and re-positioned) across call
+// sites.
+func copyExpr(expr ast.Expr) ast.Expr {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+		return expr
+	}
+	reparsed, err := parser.ParseExpr(buf.String())
+	if err != nil {
+		return expr
+	}
+	return reparsed
+}
+
+func (r AddParameter) Apply(pkgs []*packages.Package) (map[*ast.File]*packages.Package, error) {
+	fn, err := resolveFuncTarget(pkgs, r.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+
+	family := interfaceFamily(pkgs, fn)
+	all := append([]*types.Func{fn}, family...)
+	targets := make(map[*types.Func]bool, len(all))
+	for _, m := range all {
+		targets[m] = true
+	}
+
+	touched := make(map[*ast.File]*packages.Package)
+	for _, m := range all {
+		for _, pkg := range pkgs {
+			if f := addParamToDecl(pkg, m, r.ParamName, r.ParamType); f != nil {
+				touched[f] = pkg
+			}
+		}
+	}
+	if len(touched) == 0 {
+		return nil, fmt.Errorf("declaration of %s not found in the loaded packages", r.FunctionName)
+	}
+
+	callSites, err := rewriteCallSites(pkgs, targets, r.DefaultArg)
+	if err != nil {
+		return nil, err
+	}
+	for f, pkg := range callSites {
+		touched[f] = pkg
+	}
+
+	return touched, nil
+}
+
+// RemoveUnused deletes every package-level declaration the unused package
+// can't reach from its analysis roots.
+type RemoveUnused struct {
+	WholeProgram bool
+}
+
+func (r RemoveUnused) Description() string {
+	if r.WholeProgram {
+		return "Remove declarations unreachable from main/Test* (whole-program)"
+	}
+	return "Remove declarations unreachable from exported package API"
+}
+
+func (r RemoveUnused) Apply(pkgs []*packages.Package) (map[*ast.File]*packages.Package, error) {
+	mode := unused.Package
+	if r.WholeProgram {
+		mode = unused.WholeProgram
+	}
+
+	results := unused.Analyze(pkgs, mode)
+	if len(results) == 0 {
+		return nil, nil
+	}
+	for _, res := range results {
+		fmt.Printf("Removing unused %s (%s) at %s\n", res.Object.Name(), res.Object, res.Pos)
+	}
+	return unused.Remove(pkgs, results), nil
+}
+
+// loadPackages loads the package graph rooted at pattern using go/packages,
+// resolving full type information for every package it contains.
+func loadPackages(pattern string) ([]*packages.Package, error) {
+	cfg := &packages.Config{Mode: loadMode, Dir: ""}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages for %q: %v", pattern, err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("errors while type-checking %q", pattern)
+	}
+	return pkgs, nil
 }
 
-// Apply refactorings to a file
-func applyRefactorings(filename string, refactorings []Refactoring) error {
-	// Create a file set for position information
-	fset := token.NewFileSet()
-	
-	// Parse the file
-	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+// applyRefactorings loads the package graph under *inputDir and applies
+// each refactoring across it, writing back every file it touched.
+func applyRefactorings(refactorings []Refactoring) error {
+	pattern := *inputDir
+	if *recursive {
+		pattern = strings.TrimSuffix(pattern, "/") + "/..."
+	}
+
+	pkgs, err := loadPackages(pattern)
 	if err != nil {
-		return fmt.Errorf("failed to parse file: %v", err)
+		return err
+	}
+
+	// Snapshot every file's text before any refactoring mutates its AST, so
+	// a dry run can still show what would change.
+	before := make(map[*ast.File]string)
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Syntax {
+			var buf bytes.Buffer
+			if format.Node(&buf, pkg.Fset, f) == nil {
+				before[f] = buf.String()
+			}
+		}
 	}
-	
-	// Apply each refactoring
-	changed := false
+
+	touched := make(map[*ast.File]*packages.Package)
 	for _, r := range refactorings {
-		refChanged, err := r.Apply(fset, file)
+		files, err := r.Apply(pkgs)
 		if err != nil {
-			return fmt.Errorf("failed to apply refactoring: %v", err)
+			return fmt.Errorf("failed to apply refactoring %q: %v", r.Description(), err)
+		}
+		for f, pkg := range files {
+			touched[f] = pkg
+			fmt.Printf("Applied '%s' in %s\n", r.Description(), pkg.Fset.Position(f.Pos()).Filename)
 		}
-		if refChanged {
-			changed = true
-			fmt.Printf("Applied '%s' to %s\n", r.Description(), filename)
+	}
+
+	if len(touched) == 0 {
+		fmt.Println("No changes made")
+		return nil
+	}
+
+	if !*write {
+		for f, pkg := range touched {
+			filename := pkg.Fset.Position(f.Pos()).Filename
+			var buf bytes.Buffer
+			if err := format.Node(&buf, pkg.Fset, f); err != nil {
+				return fmt.Errorf("failed to format %s: %v", filename, err)
+			}
+			printDiff(filename, before[f], buf.String())
 		}
+		fmt.Printf("Changes not written (use -write to save changes)\n")
+		return nil
 	}
-	
-	// If the file was changed and we should write the changes
-	if changed && *write {
-		// Format the file
+
+	for f, pkg := range touched {
+		filename := pkg.Fset.Position(f.Pos()).Filename
 		var buf bytes.Buffer
-		if err := format.Node(&buf, fset, file); err != nil {
-			return fmt.Errorf("failed to format file: %v", err)
+		if err := format.Node(&buf, pkg.Fset, f); err != nil {
+			return fmt.Errorf("failed to format %s: %v", filename, err)
 		}
-		
-		// Write the changes back to the file
 		if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
-			return fmt.Errorf("failed to write file: %v", err)
+			return fmt.Errorf("failed to write %s: %v", filename, err)
 		}
-		
 		fmt.Printf("Wrote changes to %s\n", filename)
-	} else if changed {
-		fmt.Printf("Changes not written (use -write to save changes)\n")
-	} else {
-		fmt.Printf("No changes made to %s\n", filename)
 	}
-	
+
 	return nil
 }
 
+// printDiff prints a minimal unified-style diff between before and after,
+// line by line. It's not a full Myers diff, just enough to show what a
+// dry run would change without writing anything.
+func printDiff(filename, before, after string) {
+	if before == after {
+		return
+	}
+	fmt.Printf("--- %s\n+++ %s\n", filename, filename)
+
+	beforeLines := strings.Split(before, "\n")
+	afterLines := strings.Split(after, "\n")
+
+	i, j := 0, 0
+	for i < len(beforeLines) || j < len(afterLines) {
+		switch {
+		case i < len(beforeLines) && j < len(afterLines) && beforeLines[i] == afterLines[j]:
+			i++
+			j++
+		case j < len(afterLines) && !contains(beforeLines[i:], afterLines[j]):
+			fmt.Printf("+%s\n", afterLines[j])
+			j++
+		case i < len(beforeLines):
+			fmt.Printf("-%s\n", beforeLines[i])
+			i++
+		default:
+			fmt.Printf("+%s\n", afterLines[j])
+			j++
+		}
+	}
+}
+
+func contains(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
 func main() {
 	// Parse command line flags
 	flag.Parse()
-	
+
 	// Create refactorings based on flags
 	var refactorings []Refactoring
-	
+
 	if *renameFn != "" {
 		parts := strings.Split(*renameFn, ":")
 		if len(parts) != 2 {
@@ -287,7 +734,7 @@ func main() {
 			NewName: parts[1],
 		})
 	}
-	
+
 	if *renameType != "" {
 		parts := strings.Split(*renameType, ":")
 		if len(parts) != 2 {
@@ -299,7 +746,7 @@ func main() {
 			NewName: parts[1],
 		})
 	}
-	
+
 	if *renameVar != "" {
 		parts := strings.Split(*renameVar, ":")
 		if len(parts) != 2 {
@@ -311,57 +758,38 @@ func main() {
 			NewName: parts[1],
 		})
 	}
-	
+
 	if *addParam != "" {
 		parts := strings.Split(*addParam, ":")
 		if len(parts) != 3 {
 			fmt.Fprintf(os.Stderr, "Invalid format for -add-param, expected 'function:name:type'\n")
 			os.Exit(1)
 		}
+		if *defaultArg == "" {
+			fmt.Fprintf(os.Stderr, "-add-param requires -default-arg so existing call sites keep compiling\n")
+			os.Exit(1)
+		}
 		refactorings = append(refactorings, AddParameter{
 			FunctionName: parts[0],
 			ParamName:    parts[1],
 			ParamType:    parts[2],
+			DefaultArg:   *defaultArg,
 		})
 	}
-	
+
+	if *removeUnused {
+		refactorings = append(refactorings, RemoveUnused{WholeProgram: *wholeProgram})
+	}
+
 	// Check if we have any refactorings to apply
 	if len(refactorings) == 0 {
 		fmt.Println("No refactorings specified")
 		flag.Usage()
 		os.Exit(1)
 	}
-	
-	// Find Go files to process
-	var filesToProcess []string
-	
-	err := filepath.Walk(*inputDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		
-		// Skip directories unless recursive is enabled
-		if info.IsDir() && path != *inputDir && !*recursive {
-			return filepath.SkipDir
-		}
-		
-		// Process Go files
-		if !info.IsDir() && strings.HasSuffix(path, ".go") {
-			filesToProcess = append(filesToProcess, path)
-		}
-		
-		return nil
-	})
-	
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error walking directory: %v\n", err)
+
+	if err := applyRefactorings(refactorings); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-	
-	// Apply refactorings to each file
-	for _, filename := range filesToProcess {
-		if err := applyRefactorings(filename, refactorings); err != nil {
-			fmt.Fprintf(os.Stderr, "Error processing %s: %v\n", filename, err)
-		}
-	}
 }
@@ -0,0 +1,183 @@
+// Package sqldialect abstracts over the SQL syntax differences between
+// database backends, so code generators (see ../code_generator.go) can
+// target SQLite, PostgreSQL, or MySQL from one template instead of
+// hardcoding one dialect's placeholders, column types, and quoting.
+package sqldialect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Column describes a table column derived from a generated struct's field.
+type Column struct {
+	Name       string
+	GoType     string
+	PrimaryKey bool
+}
+
+// Dialect is implemented by each supported SQL backend.
+type Dialect interface {
+	// Name identifies the dialect, as accepted by the -dialect flag.
+	Name() string
+	// Placeholder returns the bound-parameter placeholder for the i'th
+	// value (0-indexed) in a statement, e.g. "?" for SQLite/MySQL or "$1"
+	// for Postgres.
+	Placeholder(i int) string
+	// TypeFor maps a Go field type to this dialect's column type.
+	TypeFor(goType string) string
+	// QuoteIdent quotes a table or column name per this dialect's rules.
+	QuoteIdent(s string) string
+	// CreateTable renders a CREATE TABLE IF NOT EXISTS statement for table
+	// with the given columns.
+	CreateTable(table string, columns []Column) string
+	// InsertReturning reports how to retrieve an inserted row's
+	// auto-generated primary key. Dialects with a RETURNING clause (only
+	// Postgres, here) return the clause to append to the INSERT statement
+	// and true; every other dialect returns "", false, telling the caller
+	// to fall back to sql.Result.LastInsertId instead.
+	InsertReturning(pk string) (clause string, ok bool)
+}
+
+// ByName resolves a -dialect flag value to a Dialect. An empty name
+// defaults to SQLite, matching the generator's previous -sqlite behavior.
+func ByName(name string) (Dialect, error) {
+	switch name {
+	case "", "sqlite":
+		return SQLite{}, nil
+	case "postgres":
+		return Postgres{}, nil
+	case "mysql":
+		return MySQL{}, nil
+	default:
+		return nil, fmt.Errorf("unknown SQL dialect %q (want sqlite, postgres, or mysql)", name)
+	}
+}
+
+// SQLite targets the SQLite dialect used by database/sql's sqlite drivers.
+type SQLite struct{}
+
+func (SQLite) Name() string          { return "sqlite" }
+func (SQLite) Placeholder(i int) string { return "?" }
+func (SQLite) QuoteIdent(s string) string { return `"` + s + `"` }
+
+func (SQLite) TypeFor(goType string) string {
+	switch goType {
+	case "string":
+		return "TEXT"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "INTEGER"
+	case "float32", "float64":
+		return "REAL"
+	case "bool":
+		return "BOOLEAN"
+	default:
+		return "BLOB"
+	}
+}
+
+func (d SQLite) CreateTable(table string, columns []Column) string {
+	return createTable(d, table, columns)
+}
+
+// InsertReturning: SQLite has no RETURNING clause support in the
+// database/sql driver APIs this generator targets, so callers always fall
+// back to LastInsertId.
+func (SQLite) InsertReturning(pk string) (string, bool) { return "", false }
+
+// Postgres targets PostgreSQL.
+type Postgres struct{}
+
+func (Postgres) Name() string            { return "postgres" }
+func (Postgres) Placeholder(i int) string { return "$" + strconv.Itoa(i+1) }
+func (Postgres) QuoteIdent(s string) string { return `"` + s + `"` }
+
+func (Postgres) TypeFor(goType string) string {
+	switch goType {
+	case "string":
+		return "TEXT"
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32":
+		return "INTEGER"
+	case "int64", "uint64":
+		return "BIGINT"
+	case "float32":
+		return "REAL"
+	case "float64":
+		return "DOUBLE PRECISION"
+	case "bool":
+		return "BOOLEAN"
+	default:
+		return "BYTEA"
+	}
+}
+
+func (d Postgres) CreateTable(table string, columns []Column) string {
+	return createTable(d, table, columns)
+}
+
+// InsertReturning: Postgres has no LastInsertId equivalent, so every
+// insert of a row with a primary key appends RETURNING to read it back in
+// the same round trip.
+func (d Postgres) InsertReturning(pk string) (string, bool) {
+	if pk == "" {
+		return "", false
+	}
+	return "RETURNING " + d.QuoteIdent(pk), true
+}
+
+// MySQL targets MySQL/MariaDB.
+type MySQL struct{}
+
+func (MySQL) Name() string            { return "mysql" }
+func (MySQL) Placeholder(i int) string { return "?" }
+func (MySQL) QuoteIdent(s string) string { return "`" + s + "`" }
+
+func (MySQL) TypeFor(goType string) string {
+	switch goType {
+	case "string":
+		return "VARCHAR(255)"
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32":
+		return "INT"
+	case "int64", "uint64":
+		return "BIGINT"
+	case "float32":
+		return "FLOAT"
+	case "float64":
+		return "DOUBLE"
+	case "bool":
+		return "BOOLEAN"
+	default:
+		return "BLOB"
+	}
+}
+
+func (d MySQL) CreateTable(table string, columns []Column) string {
+	return createTable(d, table, columns)
+}
+
+// InsertReturning: MySQL has no RETURNING clause either, so it falls back
+// to LastInsertId like SQLite.
+func (MySQL) InsertReturning(pk string) (string, bool) { return "", false }
+
+// createTable renders a CREATE TABLE IF NOT EXISTS statement shared by
+// every dialect; the only per-dialect differences are quoting, column
+// types, and how a primary key declares auto-increment.
+func createTable(d Dialect, table string, columns []Column) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", d.QuoteIdent(table))
+	for i, c := range columns {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, "\t%s %s", d.QuoteIdent(c.Name), d.TypeFor(c.GoType))
+		if c.PrimaryKey {
+			b.WriteString(" PRIMARY KEY")
+			if d.Name() == "mysql" {
+				b.WriteString(" AUTO_INCREMENT")
+			}
+		}
+	}
+	b.WriteString("\n)")
+	return b.String()
+}
@@ -0,0 +1,364 @@
+// Package unused implements a whole-program dead-symbol elimination pass
+// modeled on staticcheck's `unused` analyzer: it builds a reference graph
+// of types.Object rooted at a configurable set of entry points and reports
+// every object that graph can't reach.
+package unused
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Mode controls which package-level objects are treated as GC roots.
+type Mode int
+
+const (
+	// Package mode treats every exported package-level object as a root,
+	// alongside init, main, and anything named Test*, Benchmark*,
+	// Example*, or Fuzz*. It's the conservative default: it only
+	// flags symbols that are unused *and* unexported, or unexported and
+	// unreachable even transitively from exported API.
+	Package Mode = iota
+
+	// WholeProgram mode (the -whole-program flag) only roots init, main,
+	// and anything named Test*, Benchmark*, Example*, or Fuzz*, so
+	// exported-but-never-called API surface gets
+	// reported too. Only meaningful when analyzing a complete set of
+	// packages (a main module), not a library consumed elsewhere.
+	WholeProgram
+)
+
+// Result is a single dead symbol found by Analyze.
+type Result struct {
+	Object types.Object
+	Pos    token.Position
+	Reason string
+}
+
+// declInfo ties a package-level object to the syntax it was declared with,
+// so Analyze can both walk its body for outgoing references and, later,
+// remove it from the tree.
+type declInfo struct {
+	pkg  *packages.Package
+	file *ast.File
+	// genDecl/spec are set for types, vars, and consts; funcDecl is set
+	// for functions and methods. Exactly one pair is non-nil.
+	genDecl  *ast.GenDecl
+	spec     ast.Spec
+	funcDecl *ast.FuncDecl
+}
+
+// Analyze walks the reference graph of pkgs rooted according to mode and
+// returns every package-level types.Object that is unreachable from those
+// roots.
+func Analyze(pkgs []*packages.Package, mode Mode) []Result {
+	decls := collectDecls(pkgs)
+	roots := collectRoots(pkgs, decls, mode)
+
+	reachable := make(map[types.Object]bool, len(decls))
+	var queue []types.Object
+	enqueue := func(obj types.Object) {
+		if obj != nil && !reachable[obj] {
+			reachable[obj] = true
+			queue = append(queue, obj)
+		}
+	}
+	for _, obj := range roots {
+		enqueue(obj)
+	}
+
+	// Methods satisfying a reachable interface must be kept alive even
+	// though no call site names them directly (the call goes through the
+	// interface method, not the concrete one).
+	implementers := interfaceImplementers(pkgs)
+
+	for len(queue) > 0 {
+		obj := queue[0]
+		queue = queue[1:]
+
+		decl, ok := decls[obj]
+		if !ok {
+			continue
+		}
+		for _, ref := range referencedObjects(decl) {
+			enqueue(ref)
+		}
+
+		// If obj is (or becomes) reachable and it's an interface, every
+		// method satisfying it on every implementing type is reachable.
+		if iface, ok := ifaceOf(obj); ok {
+			for _, m := range implementers[iface] {
+				enqueue(m)
+			}
+		}
+	}
+
+	var results []Result
+	for obj, decl := range decls {
+		if reachable[obj] {
+			continue
+		}
+		if isRoot(obj, mode) {
+			continue
+		}
+		if isLinknameTarget(decl) || isCgoExport(decl) {
+			continue
+		}
+		results = append(results, Result{
+			Object: obj,
+			Pos:    decl.pkg.Fset.Position(obj.Pos()),
+			Reason: "unreachable from " + modeName(mode) + " roots",
+		})
+	}
+	return results
+}
+
+func modeName(mode Mode) string {
+	if mode == WholeProgram {
+		return "whole-program"
+	}
+	return "package"
+}
+
+// collectDecls maps every package-level object to the declaration syntax
+// that introduced it.
+func collectDecls(pkgs []*packages.Package) map[types.Object]*declInfo {
+	decls := make(map[types.Object]*declInfo)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			continue
+		}
+		for _, f := range pkg.Syntax {
+			for _, d := range f.Decls {
+				switch decl := d.(type) {
+				case *ast.FuncDecl:
+					if obj, ok := pkg.TypesInfo.Defs[decl.Name]; ok && obj != nil {
+						decls[obj] = &declInfo{pkg: pkg, file: f, funcDecl: decl}
+					}
+				case *ast.GenDecl:
+					for _, spec := range decl.Specs {
+						switch s := spec.(type) {
+						case *ast.TypeSpec:
+							if obj, ok := pkg.TypesInfo.Defs[s.Name]; ok && obj != nil {
+								decls[obj] = &declInfo{pkg: pkg, file: f, genDecl: decl, spec: s}
+							}
+						case *ast.ValueSpec:
+							for _, name := range s.Names {
+								if obj, ok := pkg.TypesInfo.Defs[name]; ok && obj != nil {
+									decls[obj] = &declInfo{pkg: pkg, file: f, genDecl: decl, spec: s}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// collectRoots returns every object treated as a GC root under mode.
+func collectRoots(pkgs []*packages.Package, decls map[types.Object]*declInfo, mode Mode) []types.Object {
+	var roots []types.Object
+	for obj := range decls {
+		if isRoot(obj, mode) {
+			roots = append(roots, obj)
+		}
+	}
+	return roots
+}
+
+func isRoot(obj types.Object, mode Mode) bool {
+	name := obj.Name()
+	switch {
+	case name == "init" || name == "main":
+		return true
+	case strings.HasPrefix(name, "Test"), strings.HasPrefix(name, "Benchmark"), strings.HasPrefix(name, "Example"), strings.HasPrefix(name, "Fuzz"):
+		return true
+	case mode == Package && token.IsExported(name):
+		return true
+	default:
+		return false
+	}
+}
+
+// referencedObjects returns every types.Object that decl's body or
+// initializer mentions.
+func referencedObjects(decl *declInfo) []types.Object {
+	info := decl.pkg.TypesInfo
+	var node ast.Node
+	switch {
+	case decl.funcDecl != nil:
+		node = decl.funcDecl
+	default:
+		node = decl.spec
+	}
+	if node == nil {
+		return nil
+	}
+
+	var refs []types.Object
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.Ident:
+			if obj, ok := info.Uses[x]; ok {
+				refs = append(refs, obj)
+			}
+		case *ast.SelectorExpr:
+			if sel, ok := info.Selections[x]; ok {
+				refs = append(refs, sel.Obj())
+			}
+		}
+		return true
+	})
+	return refs
+}
+
+func ifaceOf(obj types.Object) (*types.Interface, bool) {
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil, false
+	}
+	iface, ok := tn.Type().Underlying().(*types.Interface)
+	return iface, ok
+}
+
+// interfaceImplementers maps each interface type to the methods (across
+// every loaded package) that satisfy it, so those methods can be kept
+// alive whenever the interface itself is reachable.
+func interfaceImplementers(pkgs []*packages.Package) map[*types.Interface][]types.Object {
+	implementers := make(map[*types.Interface][]types.Object)
+
+	var ifaces []*types.Interface
+	var named []*types.Named
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			tn, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			switch t := tn.Type().Underlying().(type) {
+			case *types.Interface:
+				ifaces = append(ifaces, t)
+			}
+			if n, ok := tn.Type().(*types.Named); ok {
+				named = append(named, n)
+			}
+		}
+	}
+
+	for _, iface := range ifaces {
+		for _, n := range named {
+			if !types.Implements(n, iface) && !types.Implements(types.NewPointer(n), iface) {
+				continue
+			}
+			for i := 0; i < n.NumMethods(); i++ {
+				m := n.Method(i)
+				for j := 0; j < iface.NumMethods(); j++ {
+					if iface.Method(j).Name() == m.Name() {
+						implementers[iface] = append(implementers[iface], m)
+					}
+				}
+			}
+		}
+	}
+	return implementers
+}
+
+// isLinknameTarget reports whether decl carries a //go:linkname directive,
+// which makes it reachable from outside the type-checked graph (the linker
+// resolves the reference at build time, invisible to go/types).
+func isLinknameTarget(decl *declInfo) bool {
+	return declDoc(decl) != nil && hasDirective(declDoc(decl), "go:linkname")
+}
+
+// isCgoExport reports whether decl carries a //export directive, making it
+// a root for the C caller that cgo generates bindings for.
+func isCgoExport(decl *declInfo) bool {
+	return declDoc(decl) != nil && hasDirective(declDoc(decl), "export")
+}
+
+func declDoc(decl *declInfo) *ast.CommentGroup {
+	if decl.funcDecl != nil {
+		return decl.funcDecl.Doc
+	}
+	if decl.genDecl != nil {
+		return decl.genDecl.Doc
+	}
+	return nil
+}
+
+func hasDirective(doc *ast.CommentGroup, directive string) bool {
+	for _, c := range doc.List {
+		text := strings.TrimPrefix(c.Text, "//")
+		if strings.HasPrefix(strings.TrimSpace(text), directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove deletes each dead declaration named in results from its package
+// syntax, honoring grouped GenDecl specs: a var/const/type removed from a
+// `var ( ... )` block only drops its own spec (and the whole block once its
+// last spec is gone), while a standalone declaration is dropped from
+// file.Decls entirely. Comment groups, doc comments included, are left in
+// file.Comments, so a formatted file still prints them where the removed
+// declaration was. It returns every file it touched, ready to be formatted and
+// written back by the caller (mirroring how applyRefactorings handles
+// other Refactorings).
+func Remove(pkgs []*packages.Package, results []Result) map[*ast.File]*packages.Package {
+	decls := collectDecls(pkgs)
+	touched := make(map[*ast.File]*packages.Package)
+
+	for _, r := range results {
+		decl, ok := decls[r.Object]
+		if !ok {
+			continue
+		}
+
+		if decl.funcDecl != nil {
+			removeDecl(decl.file, decl.funcDecl)
+			touched[decl.file] = decl.pkg
+			continue
+		}
+
+		if decl.genDecl != nil && decl.spec != nil {
+			removeSpec(decl.genDecl, decl.spec)
+			if len(decl.genDecl.Specs) == 0 {
+				removeDecl(decl.file, decl.genDecl)
+			}
+			touched[decl.file] = decl.pkg
+		}
+	}
+
+	return touched
+}
+
+// removeDecl drops decl from file.Decls.
+func removeDecl(file *ast.File, decl ast.Decl) {
+	for i, d := range file.Decls {
+		if d == decl {
+			file.Decls = append(file.Decls[:i], file.Decls[i+1:]...)
+			return
+		}
+	}
+}
+
+// removeSpec drops spec from genDecl.Specs.
+func removeSpec(genDecl *ast.GenDecl, spec ast.Spec) {
+	for i, s := range genDecl.Specs {
+		if s == spec {
+			genDecl.Specs = append(genDecl.Specs[:i], genDecl.Specs[i+1:]...)
+			return
+		}
+	}
+}
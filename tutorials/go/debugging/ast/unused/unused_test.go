@@ -0,0 +1,125 @@
+package unused
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const fixtureGoMod = "module fixture\n\ngo 1.21\n"
+
+// fixtureSource exercises the two cases this test package covers:
+// interface-method reachability (englishGreeter.Greet is only ever called
+// through the Greeter interface, never by name) and root detection
+// (UnusedExported is exported but never called, so it's only a root under
+// Package mode; deadHelper is unexported and uncalled, so it's never a
+// root under either mode).
+const fixtureSource = `package fixture
+
+type Greeter interface {
+	Greet() string
+}
+
+type englishGreeter struct{}
+
+func (englishGreeter) Greet() string { return "hello" }
+
+func useGreeter(g Greeter) string {
+	return g.Greet()
+}
+
+// UnusedExported is never called anywhere in this fixture.
+func UnusedExported() int { return 42 }
+
+func deadHelper() int {
+	return 1
+}
+
+func main() {
+	var g Greeter = englishGreeter{}
+	_ = useGreeter(g)
+}
+`
+
+// loadFixture writes fixtureSource to a throwaway module and type-checks it
+// via packages.Load, the same loader Analyze/Remove are built against.
+func loadFixture(t *testing.T) []*packages.Package {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(fixtureGoMod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(fixtureSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports |
+			packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+		Dir: dir,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		t.Fatalf("packages.Load: %v", err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("expected exactly one package, got %d", len(pkgs))
+	}
+	if len(pkgs[0].Errors) > 0 {
+		t.Fatalf("fixture failed to type-check: %v", pkgs[0].Errors)
+	}
+	return pkgs
+}
+
+func hasResult(results []Result, name string) bool {
+	for _, r := range results {
+		if r.Object.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TestAnalyzeKeepsInterfaceMethodAlive checks that englishGreeter.Greet is
+// kept alive even though no call site names it directly - the only call,
+// in useGreeter, goes through the Greeter interface. Analyze must mark
+// Greet reachable via interfaceImplementers once the Greeter interface
+// itself becomes reachable (from main's `var g Greeter` declaration). It
+// runs in WholeProgram mode, where the exported Greet is not itself a root.
+func TestAnalyzeKeepsInterfaceMethodAlive(t *testing.T) {
+	pkgs := loadFixture(t)
+	results := Analyze(pkgs, WholeProgram)
+
+	if hasResult(results, "Greet") {
+		t.Fatalf("englishGreeter.Greet reported unused, but it satisfies Greeter which main reaches: %+v", results)
+	}
+}
+
+// TestAnalyzePackageModeRootsExportedAPI checks that Package mode treats
+// every exported package-level function as a root (UnusedExported isn't
+// reported) while still reporting an unexported, uncalled one (deadHelper).
+func TestAnalyzePackageModeRootsExportedAPI(t *testing.T) {
+	pkgs := loadFixture(t)
+	results := Analyze(pkgs, Package)
+
+	if hasResult(results, "UnusedExported") {
+		t.Fatalf("exported UnusedExported reported unused under Package mode: %+v", results)
+	}
+	if !hasResult(results, "deadHelper") {
+		t.Fatalf("expected deadHelper (unexported, uncalled) to be reported unused, results: %+v", results)
+	}
+}
+
+// TestAnalyzeWholeProgramRootsOnlyMainAndTests checks that WholeProgram
+// mode only roots main/Test*, so an exported-but-never-called function
+// like UnusedExported is reported too - unlike under Package mode.
+func TestAnalyzeWholeProgramRootsOnlyMainAndTests(t *testing.T) {
+	pkgs := loadFixture(t)
+	results := Analyze(pkgs, WholeProgram)
+
+	if !hasResult(results, "UnusedExported") {
+		t.Fatalf("expected UnusedExported to be reported unused under WholeProgram mode, results: %+v", results)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"  // Import for side-effects: registers pprof handlers
@@ -8,7 +10,11 @@ import (
 	"runtime"
 	"runtime/debug"
 	"runtime/pprof"
+	"sort"
+	"strconv"
 	"time"
+
+	"github.com/google/pprof/profile"
 )
 
 // Memory leak simulation
@@ -17,16 +23,24 @@ var leakySlice []string
 // Function with a memory leak
 func leakyFunction() {
 	fmt.Println("Running leaky function...")
-	
-	// This slice grows unbounded, causing a memory leak
+
+	// This slice grows unbounded, causing a memory leak. Each allocation
+	// runs under a "phase=leak-simulation" pprof label so a heap profile
+	// taken mid-run can attribute the growth to this workload instead of
+	// whatever else is running (the pprof server's own goroutines, GC,
+	// etc.) when pivoting by label in `go tool pprof -tagfocus`.
+	ctx := context.Background()
 	for i := 0; i < 10000; i++ {
-		data := make([]byte, 1024*1024) // Allocate 1MB
-		s := fmt.Sprintf("Data block %d: %d bytes", i, len(data))
-		leakySlice = append(leakySlice, s)
-		
+		i := i
+		pprof.Do(ctx, pprof.Labels("phase", "leak-simulation", "iteration", strconv.Itoa(i)), func(context.Context) {
+			data := make([]byte, 1024*1024) // Allocate 1MB
+			s := fmt.Sprintf("Data block %d: %d bytes", i, len(data))
+			leakySlice = append(leakySlice, s)
+		})
+
 		// Simulate processing
 		time.Sleep(1 * time.Millisecond)
-		
+
 		// Print memory stats every 1000 iterations
 		if i%1000 == 0 {
 			printMemStats()
@@ -66,12 +80,151 @@ func saveHeapProfile(filename string) {
 	}
 }
 
+// topGrowingCallSites is how many call sites DiffSnapshot reports, ranked by
+// how much inuse_space they gained between the two snapshots.
+const topGrowingCallSites = 10
+
+// CallSite is one leaf call site from a heap profile's sample locations,
+// along with how many bytes of inuse_space it gained between two snapshots.
+type CallSite struct {
+	Function    string `json:"function"`
+	File        string `json:"file"`
+	Line        int64  `json:"line"`
+	GrowthBytes int64  `json:"growthBytes"`
+}
+
+// Report is the result of diffing two heap profiles: the call sites whose
+// retained memory grew the most between prev and curr.
+type Report struct {
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	TopGrowth []CallSite `json:"topGrowth"`
+}
+
+// loadProfile reads and parses a pprof profile written by saveHeapProfile.
+func loadProfile(path string) (*profile.Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return profile.Parse(f)
+}
+
+// inuseSpaceIndex returns the index into Sample.Value holding the
+// "inuse_space" sample type, which is what heap profiles use to report
+// currently-live bytes per call site.
+func inuseSpaceIndex(p *profile.Profile) (int, error) {
+	for i, st := range p.SampleType {
+		if st.Type == "inuse_space" {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("profile has no inuse_space sample type")
+}
+
+// accumulateGrowth adds sign*value (in the inuse_space sample index) to the
+// leaf call site of every sample in p, keyed by function/file/line so
+// samples from the same call site in different stacks are merged.
+func accumulateGrowth(p *profile.Profile, sign int64, growth map[string]*CallSite) error {
+	idx, err := inuseSpaceIndex(p)
+	if err != nil {
+		return err
+	}
+	for _, s := range p.Sample {
+		if len(s.Location) == 0 || len(s.Location[0].Line) == 0 {
+			continue
+		}
+		line := s.Location[0].Line[0]
+		fn := line.Function
+		key := fmt.Sprintf("%s:%s:%d", fn.Name, fn.Filename, line.Line)
+
+		cs, ok := growth[key]
+		if !ok {
+			cs = &CallSite{Function: fn.Name, File: fn.Filename, Line: line.Line}
+			growth[key] = cs
+		}
+		cs.GrowthBytes += sign * s.Value[idx]
+	}
+	return nil
+}
+
+// DiffSnapshot compares two heap profiles produced by saveHeapProfile and
+// ranks the call sites whose inuse_space grew the most from prev to curr,
+// the same comparison `go tool pprof -base` does, but parsed directly via
+// google/pprof/profile so this has no runtime dependency on a `go` toolchain
+// or `go tool pprof` being installed wherever this binary is deployed.
+func DiffSnapshot(prev, curr string) (*Report, error) {
+	base, err := loadProfile(prev)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", prev, err)
+	}
+	head, err := loadProfile(curr)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", curr, err)
+	}
+
+	growth := make(map[string]*CallSite)
+	if err := accumulateGrowth(base, -1, growth); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", prev, err)
+	}
+	if err := accumulateGrowth(head, 1, growth); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", curr, err)
+	}
+
+	sites := make([]CallSite, 0, len(growth))
+	for _, cs := range growth {
+		if cs.GrowthBytes > 0 {
+			sites = append(sites, *cs)
+		}
+	}
+	sort.Slice(sites, func(i, j int) bool { return sites[i].GrowthBytes > sites[j].GrowthBytes })
+	if len(sites) > topGrowingCallSites {
+		sites = sites[:topGrowingCallSites]
+	}
+
+	return &Report{From: prev, To: curr, TopGrowth: sites}, nil
+}
+
+// handleHeapDiff serves /debug/leak/diff?from=N&to=M: a differential
+// comparison between the heap_N.prof and heap_M.prof snapshots already
+// written by the background snapshot loop in startProfiler.
+func handleHeapDiff(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		http.Error(w, "both from and to query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	fromFile := fmt.Sprintf("heap_%s.prof", from)
+	toFile := fmt.Sprintf("heap_%s.prof", to)
+	for _, f := range []string{fromFile, toFile} {
+		if _, err := os.Stat(f); err != nil {
+			http.Error(w, fmt.Sprintf("snapshot %s not found: %v", f, err), http.StatusNotFound)
+			return
+		}
+	}
+
+	report, err := DiffSnapshot(fromFile, toFile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
 // Save memory profile at regular intervals
 func startProfiler() {
 	// Setup HTTP server for pprof
 	go func() {
+		http.HandleFunc("/debug/leak/diff", handleHeapDiff)
+
 		fmt.Println("Starting pprof server on :6060")
 		fmt.Println("Access profiling data at http://localhost:6060/debug/pprof/")
+		fmt.Println("Compare two snapshots at http://localhost:6060/debug/leak/diff?from=1&to=5")
 		http.ListenAndServe(":6060", nil)
 	}()
 	